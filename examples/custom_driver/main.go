@@ -20,7 +20,7 @@ func (d *CustomDriver) SetEncoding(encoding string) {
 }
 
 func main() {
-	driver := &CustomDriver{}
+	driver := app.AdaptLegacyDriver(&CustomDriver{})
 	app, err := app.NewDataCollector(
 		driver,
 		config.AppName("Custom Driver Example"),
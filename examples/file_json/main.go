@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -16,7 +17,6 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer driver.Close()
 	driver.SetEncoding("json")
 
 	app, err := app.NewDataCollector(
@@ -28,6 +28,8 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	// Flushes and closes the driver
+	defer app.Shutdown(context.Background())
 
 	app.Debug("Application started",
 		log.Attr("userID", "12345"),
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ralugr/datacollector/pkg/app"
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/drivers/cli"
+	"github.com/ralugr/datacollector/pkg/drivers/file"
+	"github.com/ralugr/datacollector/pkg/drivers/multi"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+func main() {
+	fileDriver, err := file.NewWriter("log_multi.txt")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Errors go to the console as plain text, everything goes to the file as json
+	driver, err := multi.NewWriter(
+		multi.Destination{Driver: cli.NewWriter(), MinLevel: log.ErrorLevel, Encoding: cli.PlainEncoding},
+		multi.Destination{Driver: fileDriver, Encoding: file.JSONEncoding},
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	app, err := app.NewDataCollector(
+		driver,
+		config.AppName("Multi Driver"),
+		config.LogLevel(log.DebugLevel),
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// Flushes and closes both drivers
+	defer app.Shutdown(context.Background())
+
+	app.Debug("Only written to the file", log.Attr("userID", "12345"))
+	app.Error("Written to the console and the file", log.Attr("attempt", 3))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -8,17 +9,24 @@ import (
 
 	"github.com/ralugr/datacollector/pkg/app"
 	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/drivers/async"
 	"github.com/ralugr/datacollector/pkg/drivers/file"
 	"github.com/ralugr/datacollector/pkg/log"
 )
 
 func main() {
-	driver, err := file.NewWriter("log_file.txt")
+	fileDriver, err := file.NewWriter("log_file.txt")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Write from a background goroutine so the logging goroutines never wait for the disk
+	driver, err := async.NewWriter(fileDriver, async.QueueSize(100), async.Overflow(async.Block))
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	defer driver.Close()
 
 	// Initialize DataCollector (also passed by pointer)
 	app, err := app.NewDataCollector(
@@ -29,6 +37,8 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	// Flushes and closes the driver
+	defer app.Shutdown(context.Background())
 
 	var wg sync.WaitGroup
 
@@ -2,7 +2,10 @@ module github.com/ralugr/datacollector
 
 go 1.22.5
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.9.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -1,27 +1,19 @@
 package app
 
 import (
+	"context"
+
 	"github.com/ralugr/datacollector/pkg/config"
 	"github.com/ralugr/datacollector/pkg/log"
 )
 
 // App is a wrapper around the application struct that provides logging and transaction functionality.
-// It enables logging at various levels (Debug, Info, Warning, Error) and supports starting new transactions
+// It enables logging at various levels (Trace, Debug, Info, Notice, Warning, Error, Critical, Fatal)
+// and supports starting new transactions
 type App struct {
 	*application
 }
 
-// Driver is an interface that defines the format and the output of the logs.
-// - RecordLog: Used to record a log entry.
-// - SetEncoding: Configures the encoding format for the log (e.g., JSON, plain text).
-//
-// Available drivers are - file.Writer for logging plain text or json logs into a file.
-//   - cli.Writer for logging plain text or json into console output.
-type Driver interface {
-	RecordLog(logInfo log.Entry)
-	SetEncoding(encoding string)
-}
-
 // NewDataCollector initializes a new App instance with a driver and configuration options.
 // There are a couple of predefined drivers: file.Writer and cli.Writer or the user can define a custom deriver.
 // If any configuration option sets an error, it returns that error and halts initialization.
@@ -37,18 +29,94 @@ func NewDataCollector(driver Driver, opts ...config.ConfigOption) (*App, error)
 		}
 	}
 
-	return &App{
+	a := &App{
 		application: newApplication(driver, cfg),
-	}, nil
+	}
+	if cfg.HandleSignals {
+		a.handleSignals()
+	}
+
+	return a, nil
 }
 
 // StartTransaction begins a new transaction within the App.
-// It accepts optional attributes that can be attached to the transaction for additional context.
+// It accepts optional attributes that are added to every entry logged within the transaction.
 func (a *App) StartTransaction(attributes ...log.Attrb) *Transaction {
 
 	return a.startTransaction(attributes...)
 }
 
+// With returns a Logger that adds the given attributes to every entry, e.g. the component or the tenant.
+func (a *App) With(attributes ...log.Attrb) *Logger {
+	return newLogger(a.application, nil, attributes...)
+}
+
+// Named returns a Logger that sets the logger name of every entry, e.g. the subsystem.
+func (a *App) Named(name string) *Logger {
+	return newLogger(a.application, nil).Named(name)
+}
+
+// Level returns the current log level of the App.
+func (a *App) Level() log.Level {
+	return a.level.Level()
+}
+
+// SetLevel changes the log level of the App and of all its transactions while the App is running.
+func (a *App) SetLevel(level log.Level) error {
+	return a.setLevel(level)
+}
+
+// LoggerLevels returns the levels of the named loggers, i.e. the level rules with the config.LoggerKey key.
+func (a *App) LoggerLevels() map[string]log.Level {
+	return a.getLoggerLevels()
+}
+
+// SetLoggerLevel sets the log level of the entries logged by the named logger and its children
+// (see App.Named), regardless of the App level. An empty level removes the logger level.
+// It updates the level rule of the logger or adds one, see config.LevelRule.
+func (a *App) SetLoggerLevel(logger string, level log.Level) error {
+	return a.setLoggerLevel(logger, level)
+}
+
+// LevelRules returns the rules that override the log level, see config.LevelRule.
+func (a *App) LevelRules() []config.LevelRule {
+	return a.getLevelRules()
+}
+
+// SetLevelRules replaces the rules that override the log level while the App is running.
+func (a *App) SetLevelRules(rules ...config.LevelRule) error {
+	return a.setLevelRules(rules)
+}
+
+// RedactionCount returns the number of sensitive values redacted by the App and its transactions so far,
+// see config.RedactKeys, config.RedactPatterns and log.Redacted.
+func (a *App) RedactionCount() int64 {
+	return a.redactor.count.Load()
+}
+
+// Sync writes the buffered entries and makes them durable, so they are not lost if the process
+// or the machine crashes right after. It is meant to be called before risky operations.
+// Drivers that do not implement Syncer are flushed instead.
+func (a *App) Sync(ctx context.Context) error {
+	return a.sync(ctx)
+}
+
+// Shutdown gracefully stops the App. New log entries are discarded from this point on,
+// every transaction that is still active is ended with the StatusCancelled status,
+// and then the driver is flushed and closed.
+// The driver errors are joined and returned to the caller. If ctx is done before the driver
+// is closed, Shutdown returns without waiting any longer.
+// Only the first call has an effect.
+func (a *App) Shutdown(ctx context.Context) error {
+	return a.shutdown(ctx)
+}
+
+// Trace logs a message at the Trace level.
+// It allows optional attributes to be passed in for additional logging context.
+func (a *App) Trace(msg string, attributes ...log.Attrb) {
+	a.log(log.TraceLevel, msg, attributes...)
+}
+
 // Debug logs a message at the Debug level.
 // It allows optional attributes to be passed in for additional logging context.
 func (a *App) Debug(msg string, attributes ...log.Attrb) {
@@ -61,6 +129,12 @@ func (a *App) Info(msg string, attributes ...log.Attrb) {
 	a.log(log.InfoLevel, msg, attributes...)
 }
 
+// Notice logs a message at the Notice level.
+// It allows optional attributes to be passed in for additional logging context.
+func (a *App) Notice(msg string, attributes ...log.Attrb) {
+	a.log(log.NoticeLevel, msg, attributes...)
+}
+
 // Warning logs a message at the Warning level.
 // It allows optional attributes to be passed in for additional logging context.
 func (a *App) Warning(msg string, attributes ...log.Attrb) {
@@ -72,3 +146,58 @@ func (a *App) Warning(msg string, attributes ...log.Attrb) {
 func (a *App) Error(msg string, attributes ...log.Attrb) {
 	a.log(log.ErrorLevel, msg, attributes...)
 }
+
+// Critical logs a message at the Critical level.
+// It allows optional attributes to be passed in for additional logging context.
+func (a *App) Critical(msg string, attributes ...log.Attrb) {
+	a.log(log.CriticalLevel, msg, attributes...)
+}
+
+// Fatal logs a message at the Fatal level, shuts the App down to flush and close the driver,
+// and then exits the process with the code set by config.FatalExitCode (1 by default).
+// The shutdown is abandoned after the config.ShutdownTimeout (5 seconds by default).
+func (a *App) Fatal(msg string, attributes ...log.Attrb) {
+	a.fatal(msg, attributes...)
+}
+
+// TraceContext logs a message at the Trace level within the span or the transaction carried by ctx.
+// Without either, it behaves like Trace.
+func (a *App) TraceContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.TraceLevel, msg, attributes...)
+}
+
+// DebugContext logs a message at the Debug level within the span or the transaction carried by ctx.
+// Without either, it behaves like Debug.
+func (a *App) DebugContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.DebugLevel, msg, attributes...)
+}
+
+// InfoContext logs a message at the Info level within the span or the transaction carried by ctx.
+// Without either, it behaves like Info.
+func (a *App) InfoContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.InfoLevel, msg, attributes...)
+}
+
+// NoticeContext logs a message at the Notice level within the span or the transaction carried by ctx.
+// Without either, it behaves like Notice.
+func (a *App) NoticeContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.NoticeLevel, msg, attributes...)
+}
+
+// WarningContext logs a message at the Warning level within the span or the transaction carried by ctx.
+// Without either, it behaves like Warning.
+func (a *App) WarningContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.WarnLevel, msg, attributes...)
+}
+
+// ErrorContext logs a message at the Error level within the span or the transaction carried by ctx.
+// Without either, it behaves like Error.
+func (a *App) ErrorContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.ErrorLevel, msg, attributes...)
+}
+
+// CriticalContext logs a message at the Critical level within the span or the transaction carried by ctx.
+// Without either, it behaves like Critical.
+func (a *App) CriticalContext(ctx context.Context, msg string, attributes ...log.Attrb) {
+	a.logContext(ctx, log.CriticalLevel, msg, attributes...)
+}
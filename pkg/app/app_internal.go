@@ -1,7 +1,17 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/ralugr/datacollector/pkg/config"
@@ -10,37 +20,432 @@ import (
 
 const appName = "Data Collector"
 
+// exit is replaced in tests to observe the signal handler.
+var exit = os.Exit
+
 type application struct {
 	drv    Driver
 	config config.Config
 	mu     sync.Mutex
+
+	// level is shared with the transactions, so changing it affects them as well.
+	level *log.LevelVar
+	// rules override level for the entries they match, see config.LevelRule.
+	rules    []config.LevelRule
+	levelsMu sync.RWMutex
+
+	// closed is set by shutdown, after which new log entries are discarded.
+	closed atomic.Bool
+	// txns holds the transactions that have not been ended yet.
+	txns   map[*txn]struct{}
+	txnsMu sync.Mutex
+	// stopSignals stops the signal handler started by handleSignals.
+	stopSignals chan struct{}
+	// redactor replaces the sensitive values before the entries reach the driver.
+	redactor *redactor
 }
 
 func newApplication(driver Driver, cfg config.Config) *application {
 	return &application{
-		drv:    driver,
-		config: cfg,
+		drv:      driver,
+		config:   cfg,
+		level:    newLevelVar(cfg),
+		rules:    slices.Clone(cfg.LevelRules),
+		txns:     make(map[*txn]struct{}),
+		redactor: newRedactor(cfg),
+	}
+}
+
+// newLevelVar returns the shared level of the configuration, or a new one set to the configured level.
+func newLevelVar(cfg config.Config) *log.LevelVar {
+	if cfg.LevelVar != nil {
+		return cfg.LevelVar
 	}
+
+	levelVar := &log.LevelVar{}
+	levelVar.Set(cfg.LogLevel)
+	return levelVar
 }
 
 func (a *application) startTransaction(attributes ...log.Attrb) *Transaction {
-	return newTransaction(a.drv, a.config, attributes...)
+	t := newTransaction(a.drv, a.config, attributes...)
+	if !t.active {
+		return t
+	}
+
+	t.app = a
+	t.level = a.level
+	t.redactor = a.redactor
+	if a.closed.Load() {
+		t.active = false
+		return t
+	}
+
+	a.txnsMu.Lock()
+	a.txns[t.txn] = struct{}{}
+	a.txnsMu.Unlock()
+
+	return t
+}
+
+func (a *application) removeTransaction(t *txn) {
+	a.txnsMu.Lock()
+	defer a.txnsMu.Unlock()
+
+	delete(a.txns, t)
 }
 
 func (a *application) log(level log.Level, msg string, attributes ...log.Attrb) {
-	if !log.IsValid(a.config.LogLevel, level) {
+	a.emit(log.Entry{
+		Timestamp:  time.Now(),
+		Level:      level,
+		AppName:    a.config.AppName,
+		Message:    msg,
+		Attributes: attributes,
+		Source:     callerSource(a.config, 2),
+	})
+}
+
+// enabled reports whether entries of the given level may be recorded, either because of
+// the application level or because of a level rule.
+func (a *application) enabled(level log.Level) bool {
+	if a.closed.Load() {
+		return false
+	}
+	if log.IsValid(a.level.Level(), level) {
+		return true
+	}
+
+	a.levelsMu.RLock()
+	defer a.levelsMu.RUnlock()
+
+	return slices.ContainsFunc(a.rules, func(r config.LevelRule) bool {
+		return log.IsValid(r.Level, level)
+	})
+}
+
+// threshold returns the lowest level recorded for the entry: the level of the first
+// matching rule, or the application level when no rule matches.
+func (a *application) threshold(data log.Entry) log.Level {
+	a.levelsMu.RLock()
+	defer a.levelsMu.RUnlock()
+
+	return matchLevel(a.rules, data, a.level.Level())
+}
+
+// matchLevel returns the level of the first rule matching the entry, or fallback.
+func matchLevel(rules []config.LevelRule, data log.Entry, fallback log.Level) log.Level {
+	for _, rule := range rules {
+		if rule.Matches(data) {
+			return rule.Level
+		}
+	}
+	return fallback
+}
+
+func (a *application) setLevel(level log.Level) error {
+	return a.level.Set(level)
+}
+
+// setLoggerLevel sets the level of the named logger, an empty level removes it.
+// The rule of an existing logger is updated in place. A new rule is placed before the rules
+// of its parent loggers, so the closest parent logger's level applies to a child logger.
+func (a *application) setLoggerLevel(logger string, level log.Level) error {
+	if logger == "" {
+		return fmt.Errorf("logger name is required")
+	}
+
+	a.levelsMu.Lock()
+	defer a.levelsMu.Unlock()
+
+	i := slices.IndexFunc(a.rules, func(r config.LevelRule) bool {
+		return r.Key == config.LoggerKey && r.Value == logger
+	})
+
+	if level == "" {
+		if i >= 0 {
+			a.rules = slices.Delete(slices.Clone(a.rules), i, i+1)
+		}
+		return nil
+	}
+
+	rule := config.LevelRule{Key: config.LoggerKey, Value: logger, Level: level}
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	// The rules are copied on write, so the slices returned by getLevelRules are never modified.
+	rules := slices.Clone(a.rules)
+	if i >= 0 {
+		rules[i] = rule
+	} else {
+		parent := slices.IndexFunc(rules, func(r config.LevelRule) bool {
+			return r.Key == config.LoggerKey && strings.HasPrefix(logger, r.Value+".")
+		})
+		if parent < 0 {
+			parent = len(rules)
+		}
+		rules = slices.Insert(rules, parent, rule)
+	}
+	a.rules = rules
+	return nil
+}
+
+// getLoggerLevels returns the levels of the logger rules.
+func (a *application) getLoggerLevels() map[string]log.Level {
+	a.levelsMu.RLock()
+	defer a.levelsMu.RUnlock()
+
+	levels := make(map[string]log.Level)
+	for _, rule := range a.rules {
+		if _, ok := levels[rule.Value]; rule.Key == config.LoggerKey && !ok {
+			levels[rule.Value] = rule.Level
+		}
+	}
+	return levels
+}
+
+func (a *application) setLevelRules(rules []config.LevelRule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	a.levelsMu.Lock()
+	defer a.levelsMu.Unlock()
+
+	a.rules = slices.Clone(rules)
+	return nil
+}
+
+func (a *application) getLevelRules() []config.LevelRule {
+	a.levelsMu.RLock()
+	defer a.levelsMu.RUnlock()
+
+	return slices.Clone(a.rules)
+}
+
+// emit records data unless the application is shut down or the level is filtered out.
+// The entry then goes through the processors and the redaction before reaching the driver.
+func (a *application) emit(data log.Entry) {
+	if a.closed.Load() || !log.IsValid(a.threshold(data), data.Level) {
 		return
 	}
 
+	process(a.config, data, a.record)
+}
+
+// record redacts data and passes it to the driver, it is the last step of emit.
+func (a *application) record(data log.Entry) {
+	data = a.redactor.redact(data)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.drv.RecordLog(data); err != nil {
+		reportError(a.config, fmt.Errorf("unable to record log entry: %w", err))
+	}
+}
+
+// fatal records the entry, shuts the application down and exits the process.
+func (a *application) fatal(msg string, attributes ...log.Attrb) {
+	a.emit(log.Entry{
+		Timestamp:  time.Now(),
+		Level:      log.FatalLevel,
+		AppName:    a.config.AppName,
+		Message:    msg,
+		Attributes: attributes,
+		Source:     callerSource(a.config, 2),
+	})
+
+	ctx := context.Background()
+	if a.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.ShutdownTimeout)
+		defer cancel()
+	}
+
+	if err := a.shutdown(ctx); err != nil {
+		reportError(a.config, err)
+	}
+	exit(a.config.FatalExitCode)
+}
+
+// logContext logs within the span or the transaction carried by ctx, or directly otherwise.
+func (a *application) logContext(ctx context.Context, level log.Level, msg string, attributes ...log.Attrb) {
+	// The entry is built here rather than by span.log or txn.log, so the source is found at the same depth.
 	data := log.Entry{
 		Timestamp:  time.Now(),
 		Level:      level,
 		AppName:    a.config.AppName,
 		Message:    msg,
 		Attributes: attributes,
+		Source:     callerSource(a.config, 2),
+	}
+
+	if span := SpanFromContext(ctx); span != nil {
+		span.emit(data)
+		return
+	}
+	if txn := FromContext(ctx); txn != nil {
+		txn.emit(data)
+		return
+	}
+	a.emit(data)
+}
+
+// sync syncs the driver, or flushes it when it does not implement Syncer, unless the application is shut down.
+func (a *application) sync(ctx context.Context) error {
+	if a.closed.Load() {
+		return nil
 	}
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.drv.RecordLog(data)
+
+	if err := SyncDriver(ctx, a.drv); err != nil {
+		return fmt.Errorf("unable to sync driver: %w", err)
+	}
+	return nil
+}
+
+// shutdown stops new logging, ends the active transactions and then flushes and closes the driver.
+// Flushing and closing are abandoned when ctx is done, in which case the context error is returned.
+// Only the first call does any work, subsequent calls return nil.
+func (a *application) shutdown(ctx context.Context) error {
+	if !a.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if a.stopSignals != nil {
+		close(a.stopSignals)
+	}
+
+	a.txnsMu.Lock()
+	active := make([]*txn, 0, len(a.txns))
+	for t := range a.txns {
+		active = append(active, t)
+	}
+	a.txns = make(map[*txn]struct{})
+	a.txnsMu.Unlock()
+
+	for _, t := range active {
+		t.shutdown()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		var errs []error
+		if err := a.drv.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("unable to flush driver: %w", err))
+		}
+		if err := a.drv.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("unable to close driver: %w", err))
+		}
+		done <- errors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown interrupted before the driver was closed: %w", ctx.Err())
+	}
+}
+
+// handleSignals shuts the application down when one of the configured signals is received
+// and then exits the process with the conventional 128+signal exit code.
+func (a *application) handleSignals() {
+	signals := a.config.ShutdownSignals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	a.stopSignals = make(chan struct{})
+
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case sig := <-ch:
+			ctx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
+			defer cancel()
+
+			if err := a.shutdown(ctx); err != nil {
+				reportError(a.config, err)
+			}
+
+			code := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(s)
+			}
+			exit(code)
+		case <-a.stopSignals:
+		}
+	}()
+}
+
+// mergeAttributes returns the base attributes followed by the overrides.
+// When a key is present in both, the base attribute is left out so the override takes precedence.
+func mergeAttributes(base []log.Attrb, overrides []log.Attrb) []log.Attrb {
+	if len(base) == 0 {
+		return overrides
+	}
+	if len(overrides) == 0 {
+		return base
+	}
+
+	merged := make([]log.Attrb, 0, len(base)+len(overrides))
+	for _, attr := range base {
+		if !slices.ContainsFunc(overrides, func(o log.Attrb) bool { return o.Key == attr.Key }) {
+			merged = append(merged, attr)
+		}
+	}
+
+	for _, attr := range overrides {
+		// A group overriding a group is merged with it, so the attributes of both are kept.
+		if attr.Value.Kind() == log.KindGroup {
+			i := slices.IndexFunc(base, func(b log.Attrb) bool { return b.Key == attr.Key })
+			if i >= 0 && base[i].Value.Kind() == log.KindGroup {
+				attr = log.Group(attr.Key, mergeAttributes(base[i].Value.Group(), attr.Value.Group())...)
+			}
+		}
+		merged = append(merged, attr)
+	}
+	return merged
+}
+
+// callerSource returns the location of the code that called the public logging method, or nil when
+// the source is not recorded. depth is the number of frames between the function calling callerSource
+// and that code, e.g. 2 for App.Info calling application.log. The configured CallerSkip is added to it.
+func callerSource(cfg config.Config, depth int) *log.Frame {
+	if !cfg.AddSource {
+		return nil
+	}
+
+	var pcs [1]uintptr
+	// Skip runtime.Callers and callerSource.
+	if runtime.Callers(depth+2+cfg.CallerSkip, pcs[:]) == 0 {
+		return nil
+	}
+	return sourceFrame(pcs[0])
+}
+
+// sourceFrame returns the location of the program counter pc.
+func sourceFrame(pc uintptr) *log.Frame {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &log.Frame{Function: frame.Function, File: frame.File, Line: frame.Line}
+}
+
+// reportError forwards err to the configured error handler or prints it to stderr.
+func reportError(cfg config.Config, err error) {
+	if cfg.ErrorHandler != nil {
+		cfg.ErrorHandler(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%v: %v\n", appName, err)
 }
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"os"
 	"testing"
 	"time"
 
@@ -14,12 +17,45 @@ type MockDriver struct {
 	mock.Mock
 }
 
-func (m *MockDriver) SetEncoding(encoding string) {
-	m.Called(encoding)
+func (m *MockDriver) SetEncoding(encoding string) error {
+	return m.Called(encoding).Error(0)
 }
 
-func (m *MockDriver) RecordLog(entry log.Entry) {
-	m.Called(entry)
+func (m *MockDriver) RecordLog(entry log.Entry) error {
+	return m.Called(entry).Error(0)
+}
+
+func (m *MockDriver) Flush(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *MockDriver) Close(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+// newTestApp creates an App with the options applied to the default config.
+// Its driver accepts every entry, the other calls have to be set up by the test.
+func newTestApp(t *testing.T, opts ...config.ConfigOption) (*App, *MockDriver) {
+	cfg := config.DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	assert.NoError(t, cfg.Error)
+
+	driver := new(MockDriver)
+	driver.On("RecordLog", mock.Anything).Return(nil)
+	return &App{application: newApplication(driver, cfg)}, driver
+}
+
+// recordedEntries returns the entries passed to RecordLog, in order.
+func recordedEntries(driver *MockDriver) []log.Entry {
+	var entries []log.Entry
+	for _, call := range driver.Calls {
+		if call.Method == "RecordLog" {
+			entries = append(entries, call.Arguments.Get(0).(log.Entry))
+		}
+	}
+	return entries
 }
 
 func TestNewApplication(t *testing.T) {
@@ -66,7 +102,7 @@ func TestApplicationLogValid(t *testing.T) {
 		return entry.Level == expectedEntry.Level &&
 			entry.AppName == expectedEntry.AppName &&
 			entry.Message == expectedEntry.Message
-	})).Return()
+	})).Return(nil)
 
 	app.log(log.DebugLevel, "Test debug message", log.Attr("key", "value"))
 
@@ -82,7 +118,7 @@ func TestApplicationLogInvalid(t *testing.T) {
 
 	app := newApplication(driver, cfg)
 
-	driver.On("RecordLog", mock.Anything).Return()
+	driver.On("RecordLog", mock.Anything).Return(nil)
 
 	app.log(log.DebugLevel, "This should not log", log.Attr("key", "value"))
 
@@ -104,7 +140,7 @@ func TestApplicationLogConcurrency(t *testing.T) {
 		Attributes: []log.Attrb{log.Attr("key", "value")},
 	}
 
-	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return()
+	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return(nil)
 
 	done := make(chan bool)
 	go func() {
@@ -123,3 +159,195 @@ func TestApplicationLogConcurrency(t *testing.T) {
 		return entry.Level == expectedEntry.Level && entry.Message == expectedEntry.Message
 	}))
 }
+
+func TestApplicationLogReportsDriverError(t *testing.T) {
+	driver := new(MockDriver)
+	driverErr := errors.New("disk full")
+
+	var reported error
+	cfg := config.DefaultConfig()
+	config.ErrorHandler(func(err error) { reported = err })(&cfg)
+
+	app := newApplication(driver, cfg)
+
+	driver.On("RecordLog", mock.Anything).Return(driverErr)
+
+	app.log(log.InfoLevel, "Test message")
+
+	assert.ErrorIs(t, reported, driverErr, "Driver error should be passed to the error handler")
+}
+
+func TestApplicationShutdown(t *testing.T) {
+	driver := new(MockDriver)
+	flushErr := errors.New("flush failed")
+	closeErr := errors.New("close failed")
+
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("Flush", mock.Anything).Return(flushErr)
+	driver.On("Close", mock.Anything).Return(closeErr)
+
+	err := app.shutdown(context.Background())
+
+	assert.ErrorIs(t, err, flushErr, "Flush error should be returned")
+	assert.ErrorIs(t, err, closeErr, "Close error should be returned")
+	driver.AssertCalled(t, "Flush", mock.Anything)
+	driver.AssertCalled(t, "Close", mock.Anything)
+}
+
+type syncMockDriver struct {
+	MockDriver
+}
+
+func (m *syncMockDriver) Sync(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func TestApplicationSync(t *testing.T) {
+	driver := new(MockDriver)
+	driver.On("Flush", mock.Anything).Return(nil)
+
+	assert.NoError(t, newApplication(driver, config.DefaultConfig()).sync(context.Background()))
+	driver.AssertCalled(t, "Flush", mock.Anything)
+
+	syncer := new(syncMockDriver)
+	syncErr := errors.New("sync failed")
+	syncer.On("Sync", mock.Anything).Return(syncErr)
+
+	err := newApplication(syncer, config.DefaultConfig()).sync(context.Background())
+
+	assert.ErrorIs(t, err, syncErr, "Sync error should be returned")
+	syncer.AssertNotCalled(t, "Flush", mock.Anything)
+}
+
+type legacyMockDriver struct {
+	entries  []log.Entry
+	encoding string
+}
+
+func (d *legacyMockDriver) RecordLog(entry log.Entry) {
+	d.entries = append(d.entries, entry)
+}
+
+func (d *legacyMockDriver) SetEncoding(encoding string) {
+	d.encoding = encoding
+}
+
+func TestAdaptLegacyDriver(t *testing.T) {
+	legacy := &legacyMockDriver{}
+	driver := AdaptLegacyDriver(legacy)
+
+	assert.NoError(t, driver.SetEncoding("json"))
+	assert.NoError(t, driver.RecordLog(log.Entry{Message: "Test message"}))
+	assert.NoError(t, driver.Flush(context.Background()))
+	assert.NoError(t, driver.Close(context.Background()))
+
+	assert.Equal(t, "json", legacy.encoding, "Encoding should be forwarded")
+	assert.Len(t, legacy.entries, 1, "Entry should be forwarded")
+}
+
+func TestApplicationShutdownEndsActiveTransactions(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+	driver.On("Flush", mock.Anything).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+
+	active := app.startTransaction()
+	ended := app.startTransaction()
+	ended.end()
+
+	assert.NoError(t, app.shutdown(context.Background()))
+
+	assert.False(t, active.active, "Active transaction should be ended by shutdown")
+	driver.AssertNumberOfCalls(t, "RecordLog", 2)
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Level == log.WarnLevel && entry.TransactionID == active.id &&
+			attributeMap(entry)["status"] == string(StatusCancelled)
+	}))
+}
+
+func TestApplicationLogAfterShutdown(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+	driver.On("Flush", mock.Anything).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+
+	txn := app.startTransaction()
+
+	assert.NoError(t, app.shutdown(context.Background()))
+	assert.NoError(t, app.shutdown(context.Background()), "Second shutdown should be a no-op")
+
+	app.log(log.ErrorLevel, "This should not log")
+	txn.log(log.ErrorLevel, "This should not log")
+	app.startTransaction().log(log.ErrorLevel, "This should not log")
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 1)
+	driver.AssertNumberOfCalls(t, "Close", 1)
+}
+
+func TestApplicationShutdownDeadline(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	release := make(chan struct{})
+	defer close(release)
+	driver.On("Flush", mock.Anything).Run(func(mock.Arguments) { <-release }).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := app.shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Shutdown should stop waiting when the context is done")
+}
+
+func TestApplicationExtendedLevels(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.NoticeLevel
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	app.Trace("This should not log")
+	app.Info("This should not log")
+	app.Notice("Notice message")
+	app.Critical("Critical message")
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 2)
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Level == log.NoticeLevel && entry.Message == "Notice message"
+	}))
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Level == log.CriticalLevel && entry.Message == "Critical message"
+	}))
+}
+
+func TestApplicationFatal(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	config.FatalExitCode(3)(&cfg)
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+	driver.On("Flush", mock.Anything).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+	defer func() { exit = os.Exit }()
+
+	app.Fatal("Unable to start", log.Attr("port", 8080))
+
+	assert.Equal(t, 3, exitCode, "Fatal should exit with the configured code")
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Level == log.FatalLevel && entry.Message == "Unable to start"
+	}))
+	driver.AssertCalled(t, "Flush", mock.Anything)
+	driver.AssertCalled(t, "Close", mock.Anything)
+}
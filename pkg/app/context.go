@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	transactionKey contextKey = iota
+	spanKey
+)
+
+// NewContext returns a copy of ctx that carries txn.
+// Use FromContext to retrieve the transaction and the App context methods (InfoContext, ErrorContext, ...)
+// to log within it. If ctx is cancelled before the transaction ends, the transaction ends with StatusCancelled.
+func NewContext(ctx context.Context, txn *Transaction) context.Context {
+	txn.watch(ctx)
+
+	return context.WithValue(ctx, transactionKey, txn)
+}
+
+// FromContext returns the transaction carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Transaction {
+	txn, _ := ctx.Value(transactionKey).(*Transaction)
+	return txn
+}
+
+// NewSpanContext returns a copy of ctx that carries span and its transaction.
+// The App context methods log within the span.
+func NewSpanContext(ctx context.Context, span *Span) context.Context {
+	if FromContext(ctx) == nil {
+		ctx = NewContext(ctx, &Transaction{txn: span.txn})
+	}
+
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
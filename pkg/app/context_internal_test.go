@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFromContext(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	assert.Nil(t, FromContext(context.Background()))
+	assert.Nil(t, SpanFromContext(context.Background()))
+
+	txn := app.startTransaction()
+	ctx := NewContext(context.Background(), txn)
+	assert.Same(t, txn, FromContext(ctx))
+
+	span := txn.StartSpan("db")
+	spanCtx := NewSpanContext(context.Background(), span)
+	assert.Same(t, span, SpanFromContext(spanCtx))
+	assert.Equal(t, txn.txn, FromContext(spanCtx).txn, "Span context should carry the span's transaction")
+}
+
+func TestApplicationLogContext(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.startTransaction()
+	span := txn.StartSpan("db")
+	txnCtx := NewContext(context.Background(), txn)
+	spanCtx := NewSpanContext(txnCtx, span)
+
+	app.logContext(context.Background(), log.InfoLevel, "App message")
+	app.logContext(txnCtx, log.InfoLevel, "Transaction message")
+	app.logContext(spanCtx, log.InfoLevel, "Span message")
+
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "App message" && entry.TransactionID == ""
+	}))
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "Transaction message" && entry.TransactionID == txn.id && entry.SpanID == ""
+	}))
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "Span message" && entry.TransactionID == txn.id && entry.SpanID == span.ID()
+	}))
+}
+
+func TestContextCancellationStatus(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.startTransaction()
+	ctx, cancel := context.WithCancel(context.Background())
+	NewContext(ctx, txn)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		txn.mu.Lock()
+		defer txn.mu.Unlock()
+		return txn.cancelErr != nil
+	}, time.Second, time.Millisecond)
+	txn.End()
+
+	summary := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	attributes := attributeMap(summary)
+	assert.Equal(t, string(StatusCancelled), attributes["status"])
+	assert.ErrorIs(t, attributes["error"].(error), context.Canceled)
+}
+
+func TestContextCancellationAfterEnd(t *testing.T) {
+	driver := new(MockDriver)
+	app := newApplication(driver, config.DefaultConfig())
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.startTransaction()
+	ctx, cancel := context.WithCancel(context.Background())
+	NewContext(ctx, txn)
+
+	txn.End()
+	cancel()
+
+	summary := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, string(StatusOK), attributeMap(summary)["status"])
+	assert.Nil(t, txn.cancelErr)
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// Driver is an interface that defines the format and the output of the logs.
+// - RecordLog: Used to record a log entry. Returns an error if the entry could not be encoded or written.
+// - SetEncoding: Configures the encoding format for the log (e.g., JSON, plain text).
+// - Flush: Writes any buffered entries to the underlying output.
+// - Close: Flushes and releases the resources held by the driver.
+//
+// Available drivers are - file.Writer for logging plain text or json logs into a file.
+//   - cli.Writer for logging plain text or json into console output.
+//
+// Drivers written against the original contract can be used through AdaptLegacyDriver.
+type Driver interface {
+	RecordLog(logInfo log.Entry) error
+	SetEncoding(encoding string) error
+	Flush(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+// Syncer is implemented by the drivers that can make the recorded entries durable,
+// e.g. by calling fsync on a file. App.Sync uses it when the driver implements it.
+type Syncer interface {
+	Sync(ctx context.Context) error
+}
+
+// SyncDriver syncs the driver if it implements Syncer and flushes it otherwise.
+// Drivers that wrap other drivers can use it to forward Sync.
+func SyncDriver(ctx context.Context, driver Driver) error {
+	if syncer, ok := driver.(Syncer); ok {
+		return syncer.Sync(ctx)
+	}
+	return driver.Flush(ctx)
+}
+
+// LegacyDriver is the original driver contract, which cannot report failures
+// and has no lifecycle methods.
+type LegacyDriver interface {
+	RecordLog(logInfo log.Entry)
+	SetEncoding(encoding string)
+}
+
+// AdaptLegacyDriver wraps a LegacyDriver so it can be passed to NewDataCollector.
+// The returned driver never reports errors and its Flush and Close methods are no-ops.
+func AdaptLegacyDriver(driver LegacyDriver) Driver {
+	return &legacyDriver{drv: driver}
+}
+
+type legacyDriver struct {
+	drv LegacyDriver
+}
+
+func (d *legacyDriver) RecordLog(logInfo log.Entry) error {
+	d.drv.RecordLog(logInfo)
+	return nil
+}
+
+func (d *legacyDriver) SetEncoding(encoding string) error {
+	d.drv.SetEncoding(encoding)
+	return nil
+}
+
+func (d *legacyDriver) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (d *legacyDriver) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// levels is the JSON document served by the level handler.
+type levels struct {
+	Level   log.Level            `json:"level,omitempty"`
+	Loggers map[string]log.Level `json:"loggers,omitempty"`
+	Rules   *[]config.LevelRule  `json:"rules,omitempty"`
+}
+
+// NewLevelHandler returns an http.Handler to inspect and change the log levels of a running App.
+//
+// GET returns the App level, the logger levels and all the level rules (see config.LevelRule):
+//
+//	{
+//	  "level": "INFO",
+//	  "loggers": {"db": "DEBUG"},
+//	  "rules": [{"key": "logger", "value": "db", "level": "DEBUG"}, {"key": "tenant", "value": "acme", "level": "DEBUG"}]
+//	}
+//
+// PUT accepts the same document. Every field is optional. The rules replace all the existing rules,
+// and then the logger levels are applied, an empty logger level removes the logger level.
+// The response contains the levels after the update.
+//
+// The handler has no authentication, it should only be exposed on an internal admin port.
+func NewLevelHandler(a *App) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if err := updateLevels(a, r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		rules := a.LevelRules()
+		json.NewEncoder(w).Encode(levels{
+			Level:   a.Level(),
+			Loggers: a.LoggerLevels(),
+			Rules:   &rules,
+		})
+	})
+}
+
+func updateLevels(a *App, r *http.Request) error {
+	var update levels
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	// Validate everything first, so a bad request does not leave the levels partially updated.
+	if update.Level != "" && !log.IsValid(update.Level, update.Level) {
+		return fmt.Errorf("Invalid value: %v", update.Level)
+	}
+	for logger, level := range update.Loggers {
+		if logger == "" {
+			return errors.New("logger name is required")
+		}
+		if level != "" && !log.IsValid(level, level) {
+			return fmt.Errorf("Invalid value: %v", level)
+		}
+	}
+	if update.Rules != nil {
+		for _, rule := range *update.Rules {
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if update.Level != "" {
+		if err := a.SetLevel(update.Level); err != nil {
+			return err
+		}
+	}
+	if update.Rules != nil {
+		if err := a.SetLevelRules(*update.Rules...); err != nil {
+			return err
+		}
+	}
+	for logger, level := range update.Loggers {
+		if err := a.SetLoggerLevel(logger, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLevelHandlerGet(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.InfoLevel
+	app := &App{application: newApplication(new(MockDriver), cfg)}
+	assert.NoError(t, app.SetLoggerLevel("db", log.DebugLevel))
+
+	rec := httptest.NewRecorder()
+	NewLevelHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"INFO","loggers":{"db":"DEBUG"},"rules":[{"key":"logger","value":"db","level":"DEBUG"}]}`, rec.Body.String())
+}
+
+func TestLevelHandlerPut(t *testing.T) {
+	app := &App{application: newApplication(new(MockDriver), config.DefaultConfig())}
+	assert.NoError(t, app.SetLoggerLevel("http", log.ErrorLevel))
+
+	body := strings.NewReader(`{"level":"WARNING","loggers":{"db":"DEBUG","http":""}}`)
+	rec := httptest.NewRecorder()
+	NewLevelHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", body))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, log.WarnLevel, app.Level())
+	assert.Equal(t, map[string]log.Level{"db": log.DebugLevel}, app.LoggerLevels())
+}
+
+func TestLevelHandlerInvalidRequest(t *testing.T) {
+	app := &App{application: newApplication(new(MockDriver), config.DefaultConfig())}
+
+	for _, body := range []string{
+		`not json`,
+		`{"level":"LOUD"}`,
+		`{"level":"INFO","loggers":{"db":"LOUD"}}`,
+		`{"level":"INFO","rules":[{"key":"tenant","value":"acme","level":"LOUD"}]}`,
+	} {
+		rec := httptest.NewRecorder()
+		NewLevelHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Equal(t, log.DebugLevel, app.Level(), "Level should not change for invalid requests")
+
+	rec := httptest.NewRecorder()
+	NewLevelHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/level", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestSetLevelAppliesToTransactions(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.WarnLevel
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.StartTransaction()
+	txn.Debug("This should not log")
+	driver.AssertNotCalled(t, "RecordLog", mock.Anything)
+
+	assert.NoError(t, app.SetLevel(log.DebugLevel))
+	txn.Debug("Transaction message")
+	app.Debug("App message")
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 2)
+}
+
+func TestSetLoggerLevel(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.WarnLevel
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	assert.NoError(t, app.SetLoggerLevel("db", log.DebugLevel))
+	assert.Error(t, app.SetLoggerLevel("db", "LOUD"))
+	assert.Error(t, app.SetLoggerLevel("", log.DebugLevel))
+
+	app.Named("db").Named("pool").Debug("Child logger message")
+	app.Named("http").Debug("This should not log")
+	app.Debug("This should not log")
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 1)
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Logger == "db.pool"
+	}))
+}
+
+func TestSharedLevelVar(t *testing.T) {
+	levelVar, err := log.NewLevelVar(log.ErrorLevel)
+	assert.NoError(t, err)
+
+	cfg := config.DefaultConfig()
+	config.LevelVar(levelVar)(&cfg)
+	app := &App{application: newApplication(new(MockDriver), cfg)}
+
+	assert.NoError(t, levelVar.Set(log.InfoLevel))
+	assert.Equal(t, log.InfoLevel, app.Level(), "App should follow the shared LevelVar")
+}
+
+func TestLevelHandlerPutRules(t *testing.T) {
+	app := &App{application: newApplication(new(MockDriver), config.DefaultConfig())}
+	assert.NoError(t, app.SetLoggerLevel("http", log.ErrorLevel))
+
+	body := strings.NewReader(`{"rules":[{"key":"tenant","value":"acme","level":"DEBUG"}],"loggers":{"db":"INFO"}}`)
+	rec := httptest.NewRecorder()
+	NewLevelHandler(app).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", body))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []config.LevelRule{
+		{Key: "tenant", Value: "acme", Level: log.DebugLevel},
+		{Key: config.LoggerKey, Value: "db", Level: log.InfoLevel},
+	}, app.LevelRules())
+}
+
+func TestLevelRulesOverrideLevel(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.InfoLevel
+	config.LevelRules(
+		config.LevelRule{Key: config.LoggerKey, Value: "http", Level: log.WarnLevel},
+		config.LevelRule{Key: "tenant", Value: "acme", Level: log.DebugLevel},
+	)(&cfg)
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	app.Named("http").Info("This should not log")
+	app.Debug("Tenant message", log.Attr("tenant", "acme"))
+	app.Debug("This should not log", log.Attr("tenant", "other"))
+	app.StartTransaction(log.Attr("tenant", "acme")).Debug("Transaction message")
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 2)
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool { return entry.Message == "Tenant message" }))
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool { return entry.Message == "Transaction message" }))
+
+	assert.True(t, app.enabled(log.DebugLevel), "Debug entries may be recorded because of the tenant rule")
+
+	assert.NoError(t, app.SetLevelRules())
+	assert.False(t, app.enabled(log.DebugLevel))
+}
+
+func TestSetLoggerLevelOrdering(t *testing.T) {
+	app := &App{application: newApplication(new(MockDriver), config.DefaultConfig())}
+
+	assert.NoError(t, app.SetLoggerLevel("http", log.ErrorLevel))
+	assert.NoError(t, app.SetLoggerLevel("http.client", log.DebugLevel))
+	assert.NoError(t, app.SetLoggerLevel("http", log.WarnLevel))
+
+	assert.Equal(t, []config.LevelRule{
+		{Key: config.LoggerKey, Value: "http.client", Level: log.DebugLevel},
+		{Key: config.LoggerKey, Value: "http", Level: log.WarnLevel},
+	}, app.LevelRules(), "Child logger rule should be placed before its parent")
+
+	assert.NoError(t, app.SetLoggerLevel("http.client", ""))
+	assert.Equal(t, map[string]log.Level{"http": log.WarnLevel}, app.LoggerLevels())
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// Logger is a lightweight logger derived from an App or a Transaction with App.With, App.Named or Transaction.With.
+// It adds its bound attributes to every entry and shares the driver, the log level and the lock of its parent.
+// Entries logged through a Logger derived from a Transaction belong to the transaction.
+type Logger struct {
+	*logger
+}
+
+// With returns a Logger that adds the given attributes to every entry, after the attributes already bound to this Logger.
+func (l *Logger) With(attributes ...log.Attrb) *Logger {
+	return l.with(attributes...)
+}
+
+// Named returns a Logger that sets the logger name of every entry.
+// The name is appended to the name of this Logger, separated by a dot.
+func (l *Logger) Named(name string) *Logger {
+	return l.named(name)
+}
+
+// Trace logs a message at the Trace level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Trace(msg string, attributes ...log.Attrb) {
+	l.log(log.TraceLevel, msg, attributes...)
+}
+
+// Debug logs a message at the Debug level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Debug(msg string, attributes ...log.Attrb) {
+	l.log(log.DebugLevel, msg, attributes...)
+}
+
+// Info logs a message at the Info level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Info(msg string, attributes ...log.Attrb) {
+	l.log(log.InfoLevel, msg, attributes...)
+}
+
+// Notice logs a message at the Notice level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Notice(msg string, attributes ...log.Attrb) {
+	l.log(log.NoticeLevel, msg, attributes...)
+}
+
+// Warning logs a message at the Warning level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Warning(msg string, attributes ...log.Attrb) {
+	l.log(log.WarnLevel, msg, attributes...)
+}
+
+// Error logs a message at the Error level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Error(msg string, attributes ...log.Attrb) {
+	l.log(log.ErrorLevel, msg, attributes...)
+}
+
+// Critical logs a message at the Critical level.
+// It allows optional attributes to be passed in for additional logging context.
+func (l *Logger) Critical(msg string, attributes ...log.Attrb) {
+	l.log(log.CriticalLevel, msg, attributes...)
+}
+
+// newLogger creates a Logger bound to the application, or to the transaction when txn is not nil.
+func newLogger(app *application, txn *txn, attributes ...log.Attrb) *Logger {
+	return &Logger{
+		logger: newPrivateLogger(app, txn, attributes...),
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+type logger struct {
+	app  *application
+	txn  *txn
+	attr []log.Attrb
+	name string
+}
+
+func newPrivateLogger(app *application, txn *txn, attributes ...log.Attrb) *logger {
+	return &logger{
+		app:  app,
+		txn:  txn,
+		attr: attributes,
+	}
+}
+
+func (l *logger) with(attributes ...log.Attrb) *Logger {
+	child := *l
+	child.attr = mergeAttributes(l.attr, attributes)
+	return &Logger{logger: &child}
+}
+
+func (l *logger) named(name string) *Logger {
+	child := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	child.name = name
+	return &Logger{logger: &child}
+}
+
+func (l *logger) log(level log.Level, msg string, attributes ...log.Attrb) {
+	data := log.Entry{
+		Timestamp:  time.Now(),
+		Level:      level,
+		Message:    msg,
+		Attributes: mergeAttributes(l.attr, attributes),
+		Logger:     l.name,
+		Source:     callerSource(l.app.config, 2),
+	}
+
+	if l.txn != nil {
+		data.AppName = l.txn.config.AppName
+		l.txn.emit(data)
+		return
+	}
+
+	data.AppName = l.app.config.AppName
+	l.app.emit(data)
+}
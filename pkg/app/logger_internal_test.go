@@ -0,0 +1,94 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoggerWith(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	logger := app.With(log.Attr("component", "billing"), log.Attr("version", "1.0"))
+	logger.With(log.Attr("tenant", "acme")).Info("Invoice sent", log.Attr("version", "2.0"))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "Invoice sent", entry.Message)
+	assert.Equal(t, app.config.AppName, entry.AppName)
+	assert.Empty(t, entry.TransactionID)
+	assert.Equal(t, []log.Attrb{
+		log.Attr("component", "billing"),
+		log.Attr("tenant", "acme"),
+		log.Attr("version", "2.0"),
+	}, entry.Attributes)
+}
+
+func TestLoggerNamed(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	app.Named("http").Named("client").Debug("Request sent")
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "http.client", entry.Logger)
+}
+
+func TestLoggerLevel(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.WarnLevel
+	app := &App{application: newApplication(driver, cfg)}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	app.With(log.Attr("component", "billing")).Info("This should not log")
+
+	driver.AssertNotCalled(t, "RecordLog", mock.Anything)
+}
+
+func TestTransactionWith(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.StartTransaction(log.Attr("userID", "12345"), log.Attr("component", "unknown"))
+	txn.With(log.Attr("component", "billing")).Named("db").Warning("Slow query")
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, txn.id, entry.TransactionID)
+	assert.Equal(t, "db", entry.Logger)
+	assert.Equal(t, []log.Attrb{
+		log.Attr("userID", "12345"),
+		log.Attr("component", "billing"),
+	}, entry.Attributes)
+	assert.Equal(t, 1, txn.counts[log.WarnLevel], "Entry should be counted by the transaction")
+}
+
+func TestLoggerWithGroups(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.StartTransaction(log.Group("http", log.String("method", "GET"), log.String("path", "/orders")))
+	txn.SetAttributes(log.Group("http", log.String("path", "/orders/42")))
+	txn.With(log.Group("http", log.Int("status", 200))).Info("Request served")
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{
+		log.Group("http",
+			log.String("method", "GET"),
+			log.String("path", "/orders/42"),
+			log.Int("status", 200),
+		),
+	}, entry.Attributes)
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// process passes data through the configured processors, in order, and calls record with every resulting entry.
+// A processor that panics is skipped: the panic is reported and the entry it received is passed on unchanged.
+func process(cfg config.Config, data log.Entry, record func(log.Entry)) {
+	if len(cfg.Processors) == 0 {
+		record(data)
+		return
+	}
+
+	entries := []log.Entry{data}
+	for _, processor := range cfg.Processors {
+		var next []log.Entry
+		for _, entry := range entries {
+			next = append(next, runProcessor(cfg, processor, entry)...)
+		}
+		entries = next
+	}
+
+	for _, entry := range entries {
+		record(entry)
+	}
+}
+
+func runProcessor(cfg config.Config, processor log.Processor, entry log.Entry) (entries []log.Entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportError(cfg, fmt.Errorf("processor %T panicked: %v", processor, r))
+			entries = []log.Entry{entry}
+		}
+	}()
+	return processor.Process(entry)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessorsMutateDropSplit(t *testing.T) {
+	enrich := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		entry.Attributes = append(entry.Attributes[:len(entry.Attributes):len(entry.Attributes)], log.String("region", "eu"))
+		return []log.Entry{entry}
+	})
+	drop := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		if entry.Message == "health check" {
+			return nil
+		}
+		return []log.Entry{entry}
+	})
+	split := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		if entry.Message != "batch" {
+			return []log.Entry{entry}
+		}
+		first, second := entry, entry
+		first.Message, second.Message = "batch 1", "batch 2"
+		return []log.Entry{first, second}
+	})
+
+	app, driver := newTestApp(t, config.Processors(enrich, drop), config.Processors(split))
+
+	app.Info("health check")
+	app.Info("batch")
+	txn := app.StartTransaction()
+	txn.Info("in transaction")
+
+	var messages []string
+	for _, entry := range recordedEntries(driver) {
+		messages = append(messages, entry.Message)
+		assert.Contains(t, entry.Attributes, log.String("region", "eu"))
+	}
+	assert.Equal(t, []string{"batch 1", "batch 2", "in transaction"}, messages)
+}
+
+func TestProcessorsOrder(t *testing.T) {
+	var seen []string
+	first := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		seen = append(seen, "first:"+entry.Message)
+		entry.Message += " first"
+		return []log.Entry{entry}
+	})
+	second := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		seen = append(seen, "second:"+entry.Message)
+		return []log.Entry{entry}
+	})
+
+	app, driver := newTestApp(t,
+		config.LogLevel(log.InfoLevel),
+		config.Processors(first, second),
+		config.RedactKeys("password"),
+	)
+
+	app.Debug("filtered")
+	app.Info("message", log.String("password", "hunter2"))
+
+	assert.Equal(t, []string{"first:message", "second:message first"}, seen, "Filtered entries should not reach the processors")
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "message first", entry.Message)
+	assert.Equal(t, log.String("password", log.RedactionMask), entry.Attributes[0], "Redaction should run after the processors")
+}
+
+func TestProcessorPanic(t *testing.T) {
+	var reported []error
+	panicking := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		panic(errors.New("boom"))
+	})
+	tag := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		entry.Logger = "tagged"
+		return []log.Entry{entry}
+	})
+
+	app, driver := newTestApp(t,
+		config.Processors(panicking, tag),
+		config.ErrorHandler(func(err error) { reported = append(reported, err) }),
+	)
+
+	app.Info("still recorded")
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "still recorded", entry.Message)
+	assert.Equal(t, "tagged", entry.Logger, "The following processors should still run")
+	if assert.Len(t, reported, 1) {
+		assert.EqualError(t, reported[0], fmt.Sprintf("processor %T panicked: boom", panicking))
+	}
+}
+
+func TestProcessorsInTransaction(t *testing.T) {
+	var txn *Transaction
+	audit := log.ProcessorFunc(func(entry log.Entry) []log.Entry {
+		switch entry.Message {
+		case "health check":
+			return nil
+		case "payment":
+			// Logging through the transaction from a processor must not deadlock.
+			txn.Notice("audited")
+		}
+		return []log.Entry{entry}
+	})
+
+	app, driver := newTestApp(t, config.Processors(audit))
+
+	txn = app.StartTransaction()
+	txn.Info("health check")
+	txn.Info("payment")
+	txn.End()
+
+	entries := recordedEntries(driver)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "audited", entries[0].Message)
+		assert.Equal(t, "payment", entries[1].Message)
+		assert.Equal(t, map[log.Level]int{log.InfoLevel: 1, log.NoticeLevel: 1}, attributeMap(entries[2])["entries"],
+			"The dropped entries should not be counted")
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// redactor replaces the sensitive values of the entries before they are passed to the driver.
+// It is shared by the application and its transactions, so the count covers all of them.
+type redactor struct {
+	// keys holds the lower-case keys of the attributes that are always redacted.
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	mode     config.RedactionMode
+	// count is the number of values replaced so far.
+	count atomic.Int64
+}
+
+func newRedactor(cfg config.Config) *redactor {
+	r := &redactor{
+		keys:     make(map[string]struct{}, len(cfg.RedactKeys)),
+		patterns: cfg.RedactPatterns,
+		mode:     cfg.RedactionMode,
+	}
+	for _, key := range cfg.RedactKeys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+	}
+	return r
+}
+
+// redact returns data with the sensitive values of the message and of the attributes replaced.
+// The attributes of data are not modified, they may be shared with other entries.
+func (r *redactor) redact(data log.Entry) log.Entry {
+	data.Message, _ = r.redactString(data.Message)
+	data.Attributes, _ = r.redactAttributes(data.Attributes)
+	return data
+}
+
+// redactAttributes returns attrs when nothing is redacted, or a copy with the redacted values otherwise.
+func (r *redactor) redactAttributes(attrs []log.Attrb) ([]log.Attrb, bool) {
+	var redacted []log.Attrb
+	for i, attr := range attrs {
+		value, changed := r.redactValue(attr)
+		if !changed {
+			if redacted != nil {
+				redacted = append(redacted, attr)
+			}
+			continue
+		}
+
+		if redacted == nil {
+			redacted = append(make([]log.Attrb, 0, len(attrs)), attrs[:i]...)
+		}
+		redacted = append(redacted, log.Attrb{Key: attr.Key, Value: value})
+	}
+
+	if redacted == nil {
+		return attrs, false
+	}
+	return redacted, true
+}
+
+func (r *redactor) redactValue(attr log.Attrb) (log.Value, bool) {
+	if _, ok := r.keys[strings.ToLower(attr.Key)]; ok {
+		r.count.Add(1)
+		return log.StringValue(r.replace(secret(attr.Value))), true
+	}
+
+	switch attr.Value.Kind() {
+	case log.KindGroup:
+		attrs, changed := r.redactAttributes(attr.Value.Group())
+		return log.GroupValue(attrs...), changed
+	case log.KindString:
+		s, changed := r.redactString(attr.Value.String())
+		return log.StringValue(s), changed
+	case log.KindError:
+		if len(r.patterns) > 0 {
+			info, changed := r.redactErrorInfo(attr.Value.ErrorInfo())
+			if changed {
+				return log.AnyValue(info), true
+			}
+		}
+	case log.KindAny:
+		if value, ok := attr.Value.Any().(log.Redacted); ok {
+			r.count.Add(1)
+			return log.StringValue(r.replace(string(value))), true
+		}
+		// Other values are matched in their text form, which replaces them when a pattern matches.
+		if len(r.patterns) > 0 {
+			s, changed := r.redactString(attr.Value.String())
+			if changed {
+				return log.StringValue(s), true
+			}
+		}
+	}
+	return attr.Value, false
+}
+
+// redactErrorInfo replaces the parts of the error messages that match the patterns, causes included.
+// The redacted description keeps the types and the stack trace, and is encoded like the error.
+func (r *redactor) redactErrorInfo(info log.ErrorInfo) (log.ErrorInfo, bool) {
+	message, changed := r.redactString(info.Message)
+	info.Message = message
+
+	if len(info.Causes) > 0 {
+		causes := make([]log.ErrorInfo, len(info.Causes))
+		for i, cause := range info.Causes {
+			redacted, causeChanged := r.redactErrorInfo(cause)
+			causes[i] = redacted
+			changed = changed || causeChanged
+		}
+		info.Causes = causes
+	}
+	return info, changed
+}
+
+// redactString replaces the parts of s that match the patterns.
+func (r *redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			changed = true
+			r.count.Add(1)
+			return r.replace(match)
+		})
+	}
+	return s, changed
+}
+
+// replace returns the mask or the hash of a sensitive value.
+func (r *redactor) replace(s string) string {
+	if r.mode == config.HashRedaction {
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	return log.RedactionMask
+}
+
+// secret returns the string of a value, including the value of a log.Redacted.
+func secret(value log.Value) string {
+	if s, ok := value.Any().(log.Redacted); ok {
+		return string(s)
+	}
+	return value.String()
+}
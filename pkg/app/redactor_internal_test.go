@@ -0,0 +1,105 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactKeys(t *testing.T) {
+	app, driver := newTestApp(t, config.RedactKeys(config.DefaultRedactKeys...))
+
+	attributes := []log.Attrb{
+		log.String("user", "bob"),
+		log.String("Password", "hunter2"),
+		log.Group("http", log.String("authorization", "Bearer abc"), log.Int("status", 200)),
+	}
+	app.Info("Login", attributes...)
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{
+		log.String("user", "bob"),
+		log.String("Password", log.RedactionMask),
+		log.Group("http", log.String("authorization", log.RedactionMask), log.Int("status", 200)),
+	}, entry.Attributes)
+	assert.Equal(t, "hunter2", attributes[1].Value.String(), "The attributes of the caller should not be modified")
+	assert.Equal(t, int64(2), app.RedactionCount())
+}
+
+func TestRedactPatterns(t *testing.T) {
+	app, driver := newTestApp(t, config.RedactPatterns(config.EmailPattern, config.CardNumberPattern))
+
+	txn := app.StartTransaction(log.String("customer", "bob@example.com"))
+	txn.Info("Charged 4111 1111 1111 1111 for alice@example.com", log.Int("amount", 42))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "Charged [REDACTED] for [REDACTED]", entry.Message)
+	assert.Equal(t, log.String("customer", log.RedactionMask), entry.Attributes[0])
+	assert.Equal(t, log.Int("amount", 42), entry.Attributes[1])
+	assert.Equal(t, int64(3), app.RedactionCount())
+}
+
+func TestRedactPatternsInErrorsAndValues(t *testing.T) {
+	app, driver := newTestApp(t, config.RedactPatterns(config.EmailPattern))
+
+	err := fmt.Errorf("login failed: %w", errors.New("unknown user bob@example.com"))
+	app.Error("Login failed", log.Err(err), log.Any("emails", []string{"alice@example.com"}), log.Any("ids", []int{1, 2}))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	info, ok := entry.Attributes[0].Value.Any().(log.ErrorInfo)
+	if assert.True(t, ok, "The error should be replaced by its redacted description") {
+		assert.Equal(t, "login failed: unknown user [REDACTED]", info.Message)
+		assert.Equal(t, "*fmt.wrapError", info.Type)
+		assert.Equal(t, "unknown user [REDACTED]", info.Causes[0].Message)
+	}
+	assert.Equal(t, log.String("emails", "[[REDACTED]]"), entry.Attributes[1])
+	assert.Equal(t, log.Any("ids", []int{1, 2}), entry.Attributes[2], "Values without a match should be kept")
+	assert.Equal(t, int64(3), app.RedactionCount())
+
+	jsonData, jsonErr := log.MarshalEntry(entry, log.KeepLast)
+	assert.NoError(t, jsonErr)
+	assert.NotContains(t, string(jsonData), "example.com")
+}
+
+func TestRedactedValue(t *testing.T) {
+	app, driver := newTestApp(t)
+
+	app.Info("Token refreshed", log.Attr("token", log.Redacted("secret-token")))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, log.String("token", log.RedactionMask), entry.Attributes[0])
+	assert.Equal(t, int64(1), app.RedactionCount())
+}
+
+func TestRedactHash(t *testing.T) {
+	app, driver := newTestApp(t, config.RedactKeys("token"), config.Redaction(config.HashRedaction))
+
+	app.Info("First", log.String("token", "abc"))
+	app.Info("Second", log.Attr("token", log.Redacted("abc")))
+	app.Info("Third", log.String("token", "other"))
+
+	first := driver.Calls[0].Arguments.Get(0).(log.Entry).Attributes[0].Value.String()
+	second := driver.Calls[1].Arguments.Get(0).(log.Entry).Attributes[0].Value.String()
+	third := driver.Calls[2].Arguments.Get(0).(log.Entry).Attributes[0].Value.String()
+
+	assert.True(t, strings.HasPrefix(first, "sha256:"), first)
+	assert.NotContains(t, first, "abc")
+	assert.Equal(t, first, second, "Equal values should have the same hash")
+	assert.NotEqual(t, first, third)
+}
+
+func TestRedactTransactionSummary(t *testing.T) {
+	app, driver := newTestApp(t, config.RedactKeys("session"))
+
+	txn := app.StartTransaction(log.String("session", "s3cr3t"))
+	txn.End()
+
+	summary := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, "Transaction completed", summary.Message)
+	assert.Equal(t, log.RedactionMask, attributeMap(summary)["session"])
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplicationHandleSignals(t *testing.T) {
+	driver := new(MockDriver)
+	driver.On("Flush", mock.Anything).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+
+	cfg := config.DefaultConfig()
+	config.ShutdownOnSignal(syscall.SIGUSR1)(&cfg)
+	config.ShutdownTimeout(time.Second)(&cfg)
+
+	exitCode := make(chan int, 1)
+	exit = func(code int) { exitCode <- code }
+	defer func() { exit = os.Exit }()
+
+	app := newApplication(driver, cfg)
+	app.handleSignals()
+
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case code := <-exitCode:
+		assert.Equal(t, 128+int(syscall.SIGUSR1), code)
+	case <-time.After(time.Second):
+		t.Fatal("signal handler did not exit")
+	}
+	assert.True(t, app.closed.Load(), "Application should be shut down")
+	driver.AssertCalled(t, "Close", mock.Anything)
+}
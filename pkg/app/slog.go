@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// SlogHandler is a slog.Handler that records the log/slog records through the App, so the libraries
+// logging with log/slog end up in the same output as the App.
+// Records logged with a context created by NewContext or NewSpanContext are recorded within
+// the transaction or the span. The slog groups are recorded as log groups, see log.Group.
+//
+// Driver errors are reported through the App's error handler, Handle never returns them.
+type SlogHandler struct {
+	app *application
+	// attrs are the attributes added with WithAttrs before any WithGroup.
+	attrs []log.Attrb
+	// groups are the groups opened with WithGroup, from the outermost to the innermost.
+	groups []slogGroup
+}
+
+// slogGroup is a group opened with WithGroup and the attributes added to it with WithAttrs.
+type slogGroup struct {
+	name  string
+	attrs []log.Attrb
+}
+
+// NewSlogHandler creates a slog.Handler backed by the App's driver and log level.
+//
+//	logger := slog.New(app.NewSlogHandler(a))
+func NewSlogHandler(a *App) *SlogHandler {
+	return &SlogHandler{
+		app: a.application,
+	}
+}
+
+// Enabled reports whether the App records entries of the given level.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.app.enabled(slogLevel(level))
+}
+
+// Handle converts the record to a log.Entry and records it.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var attributes []log.Attrb
+	r.Attrs(func(attr slog.Attr) bool {
+		attributes = appendSlogAttr(attributes, attr)
+		return true
+	})
+
+	// The record attributes belong to the innermost group, which is nested in the groups opened before it.
+	// Groups without attributes are omitted, as required by slog.Handler.
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		attributes = append(slices.Clip(h.groups[i].attrs), attributes...)
+		if len(attributes) > 0 {
+			attributes = []log.Attrb{log.Group(h.groups[i].name, attributes...)}
+		}
+	}
+	attributes = append(slices.Clip(h.attrs), attributes...)
+
+	// A zero time must be ignored, see slog.Handler, but every entry needs a timestamp.
+	timestamp := r.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	data := log.Entry{
+		Timestamp:  timestamp,
+		Level:      slogLevel(r.Level),
+		AppName:    h.app.config.AppName,
+		Message:    r.Message,
+		Attributes: attributes,
+	}
+	if h.app.config.AddSource && r.PC != 0 {
+		data.Source = sourceFrame(r.PC)
+	}
+
+	if span := SpanFromContext(ctx); span != nil {
+		span.emit(data)
+		return nil
+	}
+	if txn := FromContext(ctx); txn != nil {
+		txn.emit(data)
+		return nil
+	}
+	h.app.emit(data)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	var converted []log.Attrb
+	for _, attr := range attrs {
+		converted = appendSlogAttr(converted, attr)
+	}
+
+	h2 := *h
+	if len(h.groups) == 0 {
+		h2.attrs = append(slices.Clip(h.attrs), converted...)
+		return &h2
+	}
+
+	// The attributes belong to the innermost group.
+	h2.groups = slices.Clone(h.groups)
+	last := &h2.groups[len(h2.groups)-1]
+	last.attrs = append(slices.Clip(last.attrs), converted...)
+	return &h2
+}
+
+// WithGroup returns a handler that records the following attributes inside the group name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.groups = append(slices.Clip(h.groups), slogGroup{name: name})
+	return &h2
+}
+
+// appendSlogAttr converts attr to a log attribute and appends it.
+func appendSlogAttr(attributes []log.Attrb, attr slog.Attr) []log.Attrb {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return attributes
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		var group []log.Attrb
+		for _, groupAttr := range attr.Value.Group() {
+			group = appendSlogAttr(group, groupAttr)
+		}
+		// The attributes of a group without a key are inlined and empty groups are omitted, as required by slog.Handler.
+		if attr.Key == "" {
+			return append(attributes, group...)
+		}
+		if len(group) == 0 {
+			return attributes
+		}
+		return append(attributes, log.Group(attr.Key, group...))
+	}
+
+	return append(attributes, log.Attrb{Key: attr.Key, Value: slogValue(attr.Value)})
+}
+
+// slogValue converts a slog value to the log value of the same kind, without boxing the common types.
+func slogValue(value slog.Value) log.Value {
+	switch value.Kind() {
+	case slog.KindString:
+		return log.StringValue(value.String())
+	case slog.KindInt64:
+		return log.Int64Value(value.Int64())
+	case slog.KindUint64:
+		return log.Uint64Value(value.Uint64())
+	case slog.KindFloat64:
+		return log.Float64Value(value.Float64())
+	case slog.KindBool:
+		return log.BoolValue(value.Bool())
+	case slog.KindDuration:
+		return log.DurationValue(value.Duration())
+	case slog.KindTime:
+		return log.TimeValue(value.Time())
+	default:
+		return log.AnyValue(value.Any())
+	}
+}
+
+// slogLevel maps a slog level to the closest log level at or below it.
+// The predefined levels have the same severities as the slog levels, e.g. slog.LevelWarn+4 is ERROR.
+func slogLevel(level slog.Level) log.Level {
+	return log.LevelFor(int(level))
+}
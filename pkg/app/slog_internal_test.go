@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	driver := new(MockDriver)
+	cfg := config.DefaultConfig()
+	cfg.LogLevel = log.WarnLevel
+
+	handler := NewSlogHandler(&App{application: newApplication(driver, cfg)})
+
+	assert.False(t, handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelWarn))
+	assert.True(t, handler.Enabled(context.Background(), slog.LevelError+4))
+}
+
+func TestSlogHandlerHandle(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	logger := slog.New(NewSlogHandler(app)).With("component", "db").WithGroup("http")
+	logger.Warn("Request failed",
+		"method", "GET",
+		slog.Group("response", slog.Int("status", 500)),
+		slog.Group("", slog.Duration("elapsed", time.Second)),
+	)
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, log.WarnLevel, entry.Level)
+	assert.Equal(t, "Request failed", entry.Message)
+	assert.Equal(t, []log.Attrb{
+		log.String("component", "db"),
+		log.Group("http",
+			log.String("method", "GET"),
+			log.Group("response", log.Int64("status", 500)),
+			log.Duration("elapsed", time.Second),
+		),
+	}, entry.Attributes)
+}
+
+func TestSlogHandlerZeroTime(t *testing.T) {
+	app, driver := newTestApp(t)
+	handler := NewSlogHandler(app)
+
+	before := time.Now()
+	assert.NoError(t, handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "No time", 0)))
+	recorded := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, handler.Handle(context.Background(), slog.NewRecord(recorded, slog.LevelInfo, "With time", 0)))
+
+	entries := recordedEntries(driver)
+	assert.False(t, entries[0].Timestamp.Before(before), "A zero record time should be replaced by the current time")
+	assert.Equal(t, recorded, entries[1].Timestamp)
+}
+
+func TestSlogHandlerNestedGroups(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	logger := slog.New(NewSlogHandler(app)).WithGroup("http").With("method", "GET").WithGroup("client").WithGroup("empty")
+	logger.Info("With attributes", "retries", 2)
+	logger.Info("Without attributes")
+
+	assert.Equal(t, []log.Attrb{
+		log.Group("http",
+			log.String("method", "GET"),
+			log.Group("client", log.Group("empty", log.Int64("retries", 2))),
+		),
+	}, driver.Calls[0].Arguments.Get(0).(log.Entry).Attributes)
+	assert.Equal(t, []log.Attrb{
+		log.Group("http", log.String("method", "GET")),
+	}, driver.Calls[1].Arguments.Get(0).(log.Entry).Attributes)
+}
+
+func TestSlogHandlerTransactionFromContext(t *testing.T) {
+	driver := new(MockDriver)
+	app := &App{application: newApplication(driver, config.DefaultConfig())}
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn := app.StartTransaction(log.Attr("userID", "12345"))
+	span := txn.StartSpan("db")
+	logger := slog.New(NewSlogHandler(app))
+
+	logger.InfoContext(NewContext(context.Background(), txn), "Transaction message")
+	logger.InfoContext(NewSpanContext(context.Background(), span), "Span message")
+
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "Transaction message" && entry.TransactionID == txn.id &&
+			attributeMap(entry)["userID"] == "12345"
+	}))
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "Span message" && entry.TransactionID == txn.id && entry.SpanID == span.ID()
+	}))
+}
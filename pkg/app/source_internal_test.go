@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// line returns the line of its caller.
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func assertSource(t *testing.T, driver *MockDriver, expectedLine int) {
+	t.Helper()
+	entries := recordedEntries(driver)
+	source := entries[len(entries)-1].Source
+	if assert.NotNil(t, source) {
+		assert.Equal(t, expectedLine, source.Line)
+		assert.Contains(t, source.File, "source_internal_test.go")
+		assert.Contains(t, source.Function, "TestSource")
+	}
+}
+
+func TestSourceDisabled(t *testing.T) {
+	app, driver := newTestApp(t)
+
+	app.Info("No source")
+
+	assert.Nil(t, recordedEntries(driver)[0].Source)
+}
+
+func TestSourceEntryPoints(t *testing.T) {
+	app, driver := newTestApp(t, config.AddSource())
+	txn := app.StartTransaction()
+	span := txn.StartSpan("db")
+	ctx := NewSpanContext(context.Background(), span)
+
+	app.Info("App")
+	assertSource(t, driver, line()-1)
+
+	app.Named("billing").With(log.Attr("tenant", "acme")).Warning("Logger")
+	assertSource(t, driver, line()-1)
+
+	txn.Debug("Transaction")
+	assertSource(t, driver, line()-1)
+
+	txn.With(log.Attr("tenant", "acme")).Error("Transaction logger")
+	assertSource(t, driver, line()-1)
+
+	span.Notice("Span")
+	assertSource(t, driver, line()-1)
+
+	app.InfoContext(ctx, "Context")
+	assertSource(t, driver, line()-1)
+	entries := recordedEntries(driver)
+	assert.Equal(t, span.ID(), entries[len(entries)-1].SpanID)
+
+	app.InfoContext(context.Background(), "No context")
+	assertSource(t, driver, line()-1)
+
+	slog.New(NewSlogHandler(app)).InfoContext(ctx, "Slog")
+	assertSource(t, driver, line()-1)
+}
+
+func TestSourceFatal(t *testing.T) {
+	app, driver := newTestApp(t, config.AddSource())
+	driver.On("Flush", mock.Anything).Return(nil)
+	driver.On("Close", mock.Anything).Return(nil)
+	exit = func(int) {}
+	defer func() { exit = os.Exit }()
+
+	app.Fatal("Fatal")
+	assertSource(t, driver, line()-1)
+}
+
+// logHelper wraps App.Error, like the logging helpers of an application.
+func logHelper(app *App, msg string) {
+	app.Error(msg)
+}
+
+func TestSourceCallerSkip(t *testing.T) {
+	app, driver := newTestApp(t, config.AddSource(), config.CallerSkip(1))
+
+	logHelper(app, "Helper")
+	assertSource(t, driver, line()-1)
+}
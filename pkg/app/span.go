@@ -0,0 +1,85 @@
+package app
+
+import (
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// Span represents a timed unit of work inside a Transaction, such as a database or an outbound HTTP call.
+// Spans can be nested, every span knows the ID of its parent: the enclosing span or,
+// for the spans started directly from the Transaction, the transaction ID.
+// The attributes given when the span is started are added to every entry logged through it.
+// Make sure to call the End() function when the work is done.
+type Span struct {
+	*span
+}
+
+// ID returns the span ID, it is set on every entry logged through the span.
+func (s *Span) ID() string {
+	return s.id
+}
+
+// ParentID returns the ID of the enclosing span, or the transaction ID for top level spans.
+func (s *Span) ParentID() string {
+	return s.parentID
+}
+
+// StartSpan begins a child span of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) StartSpan(name string, attributes ...log.Attrb) *Span {
+	return s.txn.startSpan(s.id, name, attributes...)
+}
+
+// End marks the span as finished and records a "Span completed" entry with its duration.
+// Calling End more than once has no effect.
+func (s *Span) End() {
+	s.end()
+}
+
+// Trace logs a message at the Trace level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Trace(msg string, attributes ...log.Attrb) {
+	s.log(log.TraceLevel, msg, attributes...)
+}
+
+// Debug logs a message at the Debug level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Debug(msg string, attributes ...log.Attrb) {
+	s.log(log.DebugLevel, msg, attributes...)
+}
+
+// Info logs a message at the Info level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Info(msg string, attributes ...log.Attrb) {
+	s.log(log.InfoLevel, msg, attributes...)
+}
+
+// Notice logs a message at the Notice level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Notice(msg string, attributes ...log.Attrb) {
+	s.log(log.NoticeLevel, msg, attributes...)
+}
+
+// Warning logs a message at the Warning level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Warning(msg string, attributes ...log.Attrb) {
+	s.log(log.WarnLevel, msg, attributes...)
+}
+
+// Error logs a message at the Error level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Error(msg string, attributes ...log.Attrb) {
+	s.log(log.ErrorLevel, msg, attributes...)
+}
+
+// Critical logs a message at the Critical level within the context of this span.
+// Optional attributes can be provided for additional context.
+func (s *Span) Critical(msg string, attributes ...log.Attrb) {
+	s.log(log.CriticalLevel, msg, attributes...)
+}
+
+// newSpan creates and starts a new Span belonging to the given transaction.
+func newSpan(t *txn, parentID string, name string, attributes ...log.Attrb) *Span {
+	return &Span{
+		span: newPrivateSpan(t, parentID, name, attributes...),
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+type span struct {
+	id       string
+	parentID string
+	name     string
+	txn      *txn
+	attr     []log.Attrb
+	start    time.Time
+	active   bool
+	mu       sync.Mutex
+}
+
+func newPrivateSpan(t *txn, parentID string, name string, attributes ...log.Attrb) *span {
+	id, err := generateID()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to generate span ID due to error: %v. Please try again.\n", err)
+		return &span{
+			txn:    t,
+			active: false,
+		}
+	}
+	return &span{
+		id:       id,
+		parentID: parentID,
+		name:     name,
+		txn:      t,
+		attr:     attributes,
+		start:    time.Now(),
+		active:   true,
+	}
+}
+
+func (s *span) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active {
+		return
+	}
+	s.active = false
+
+	end := time.Now()
+	attributes := append([]log.Attrb{
+		log.String("span_name", s.name),
+		log.Time("start_time", s.start),
+		log.Time("end_time", end),
+		log.Duration("duration", end.Sub(s.start)),
+	}, s.attr...)
+
+	s.txn.emit(log.Entry{
+		Timestamp:    end,
+		Level:        log.InfoLevel,
+		AppName:      s.txn.config.AppName,
+		Message:      "Span completed",
+		Attributes:   attributes,
+		SpanID:       s.id,
+		ParentSpanID: s.parentID,
+	})
+}
+
+func (s *span) log(level log.Level, msg string, attributes ...log.Attrb) {
+	s.emit(log.Entry{
+		Timestamp:  time.Now(),
+		Level:      level,
+		AppName:    s.txn.config.AppName,
+		Message:    msg,
+		Attributes: attributes,
+		Source:     callerSource(s.txn.config, 2),
+	})
+}
+
+// emit records data within the span's transaction, with the attributes of the span.
+// An ended span records an error entry instead.
+func (s *span) emit(data log.Entry) {
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+
+	if !active {
+		s.txn.emit(log.Entry{
+			Timestamp: time.Now(),
+			Level:     log.ErrorLevel,
+			AppName:   appName,
+			Message:   "Span already ended!",
+			SpanID:    s.id})
+		return
+	}
+
+	data.SpanID = s.id
+	data.ParentSpanID = s.parentID
+	data.Attributes = mergeAttributes(s.attr, data.Attributes)
+	s.txn.emit(data)
+}
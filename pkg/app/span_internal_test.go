@@ -0,0 +1,106 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/config"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSpanHierarchy(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig())
+
+	parent := txn.startSpan("", "handler")
+	child := parent.StartSpan("db")
+
+	assert.NotEmpty(t, parent.ID())
+	assert.NotEqual(t, parent.ID(), child.ID(), "Span IDs should be unique")
+	assert.Equal(t, txn.id, parent.ParentID(), "Top level span parent should be the transaction")
+	assert.Equal(t, parent.ID(), child.ParentID(), "Child span parent should be the enclosing span")
+}
+
+func TestSpanLog(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig())
+	span := txn.startSpan("", "db")
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	span.Info("Query executed", log.Attr("rows", 3))
+
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Message == "Query executed" &&
+			entry.TransactionID == txn.id &&
+			entry.SpanID == span.ID() &&
+			entry.ParentSpanID == txn.id
+	}))
+}
+
+func TestSpanEnd(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig())
+	span := txn.startSpan("", "db", log.Attr("table", "products"))
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	span.End()
+	span.End()
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 1)
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+
+	assert.Equal(t, "Span completed", entry.Message)
+	assert.Equal(t, span.ID(), entry.SpanID)
+
+	attributes := attributeMap(entry)
+	assert.Equal(t, "db", attributes["span_name"])
+	assert.Equal(t, "products", attributes["table"])
+	assert.IsType(t, time.Duration(0), attributes["duration"])
+	assert.InDelta(t, attributes["end_time"].(time.Time).Sub(attributes["start_time"].(time.Time)), attributes["duration"], float64(time.Millisecond))
+}
+
+func TestSpanLogWhenEnded(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig())
+	span := txn.startSpan("", "db")
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	span.End()
+	span.Info("This should not log")
+
+	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
+		return entry.Level == log.ErrorLevel && entry.Message == "Span already ended!"
+	}))
+}
+
+func TestSpanLogIncludesTransactionAttributes(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig(), log.Attr("userID", "12345"))
+	span := txn.startSpan("", "db")
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	span.Info("Query executed", log.Attr("rows", 3))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{log.Attr("userID", "12345"), log.Attr("rows", 3)}, entry.Attributes)
+}
+
+func TestSpanLogIncludesSpanAttributes(t *testing.T) {
+	driver := new(MockDriver)
+	txn := newPrivateTxn(driver, config.DefaultConfig(), log.Attr("userID", "12345"))
+	span := txn.startSpan("", "db", log.Attr("table", "orders"), log.Attr("rows", 0))
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	span.Info("Query executed", log.Attr("rows", 3))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{log.Attr("userID", "12345"), log.Attr("table", "orders"), log.Attr("rows", 3)}, entry.Attributes,
+		"The entry's attributes should take precedence over the span's")
+}
@@ -6,18 +6,61 @@ import (
 )
 
 // Transaction represents a loggable transaction within the App.
-// It enables logging at various levels (Debug, Info, Warning, Error).
+// It enables logging at various levels (Trace, Debug, Info, Notice, Warning, Error, Critical).
+// The transaction attributes are added to every entry logged within the transaction. When an entry
+// has an attribute with the same key, the entry's attribute takes precedence.
 // Make sure to call the End() function when the transaction is not needed.
 type Transaction struct {
 	*txn
 }
 
+// Status is the outcome of a transaction, it is reported in the transaction summary.
+type Status string
+
+const (
+	StatusOK        Status = "ok"
+	StatusError     Status = "error"
+	StatusCancelled Status = "cancelled"
+)
+
 // End marks the transaction as inactive and prevents further logging within this transaction.
+// It records a "Transaction completed" summary entry with the start and end time, the duration,
+// the status, the number of entries per level and the transaction attributes.
 // Once called, any subsequent attempts to log in this transaction will result in an error log entry.
 func (t *Transaction) End() {
 	t.end()
 }
 
+// EndWithError ends the transaction like End, but marks it as failed with the given error.
+// A nil error ends the transaction successfully.
+func (t *Transaction) EndWithError(err error) {
+	t.endWithError(err)
+}
+
+// SetAttributes adds attributes to the transaction after it was started, e.g. once the user is authenticated.
+// An attribute replaces the existing transaction attribute with the same key.
+func (t *Transaction) SetAttributes(attributes ...log.Attrb) {
+	t.setAttributes(attributes...)
+}
+
+// With returns a Logger that logs within this transaction and adds the given attributes to every entry.
+// The bound attributes take precedence over the transaction attributes.
+func (t *Transaction) With(attributes ...log.Attrb) *Logger {
+	return newLogger(t.app, t.txn, attributes...)
+}
+
+// StartSpan begins a new span within this transaction, e.g. to time a database or an outbound HTTP call.
+// The span's parent ID is the transaction ID. Optional attributes can be provided for additional context.
+func (t *Transaction) StartSpan(name string, attributes ...log.Attrb) *Span {
+	return t.startSpan("", name, attributes...)
+}
+
+// Trace logs a message at the Trace level within the context of this transaction.
+// Optional attributes can be provided for additional context.
+func (t *Transaction) Trace(msg string, attributes ...log.Attrb) {
+	t.log(log.TraceLevel, msg, attributes...)
+}
+
 // Debug logs a message at the Debug level within the context of this transaction.
 // Optional attributes can be provided for additional context.
 func (t *Transaction) Debug(msg string, attributes ...log.Attrb) {
@@ -31,6 +74,12 @@ func (t *Transaction) Info(msg string, attributes ...log.Attrb) {
 	t.log(log.InfoLevel, msg, attributes...)
 }
 
+// Notice logs a message at the Notice level within the context of this transaction.
+// Optional attributes can be provided for additional context.
+func (t *Transaction) Notice(msg string, attributes ...log.Attrb) {
+	t.log(log.NoticeLevel, msg, attributes...)
+}
+
 // Warning logs a message at the Warning level within the context of this transaction.
 // Optional attributes can be provided for additional context.
 func (t *Transaction) Warning(msg string, attributes ...log.Attrb) {
@@ -43,6 +92,12 @@ func (t *Transaction) Error(msg string, attributes ...log.Attrb) {
 	t.log(log.ErrorLevel, msg, attributes...)
 }
 
+// Critical logs a message at the Critical level within the context of this transaction.
+// Optional attributes can be provided for additional context.
+func (t *Transaction) Critical(msg string, attributes ...log.Attrb) {
+	t.log(log.CriticalLevel, msg, attributes...)
+}
+
 // newTransaction creates and initializes a new Transaction instance.
 // It takes a logging driver, configuration, and optional attributes for logging.
 // The new transaction is returned to app.StartTransaction(), ready to log messages and eventually be ended.
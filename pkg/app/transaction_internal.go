@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"maps"
 	"os"
 	"sync"
 	"time"
@@ -18,7 +20,21 @@ type txn struct {
 	config config.Config
 	attr   []log.Attrb
 	active bool
-	mu     sync.Mutex
+	// level is the application's level, it is shared so level changes apply to running transactions.
+	level *log.LevelVar
+	start time.Time
+	// counts holds the number of recorded entries per level, it is reported when the transaction ends.
+	counts map[log.Level]int
+	// cancelErr is set when the context carrying the transaction is cancelled before it ends.
+	cancelErr error
+	// stopWatching unregisters the callbacks registered on the contexts carrying the transaction.
+	stopWatching []func() bool
+	mu           sync.Mutex
+	// app is the application that started the transaction, it is nil for
+	// transactions that were not registered by application.startTransaction.
+	app *application
+	// redactor is the application's redactor, it is shared so the redactions are counted together.
+	redactor *redactor
 }
 
 func newPrivateTxn(driver Driver, cfg config.Config, attributes ...log.Attrb) *txn {
@@ -31,27 +47,177 @@ func newPrivateTxn(driver Driver, cfg config.Config, attributes ...log.Attrb) *t
 		}
 	}
 	return &txn{
-		id:     id,
-		drv:    driver,
-		config: cfg,
-		attr:   attributes,
-		active: true,
+		id:       id,
+		drv:      driver,
+		config:   cfg,
+		attr:     attributes,
+		active:   true,
+		level:    newLevelVar(cfg),
+		start:    time.Now(),
+		counts:   make(map[log.Level]int),
+		redactor: newRedactor(cfg),
 	}
 }
 
 func (t *txn) end() {
+	t.finish(StatusOK, nil)
+}
+
+func (t *txn) endWithError(err error) {
+	if err == nil {
+		t.finish(StatusOK, nil)
+		return
+	}
+	t.finish(StatusError, err)
+}
+
+// shutdown ends a transaction that was still active when the application was shut down.
+// Its completion entry is recorded even though the application no longer accepts new entries.
+func (t *txn) shutdown() {
+	if data, ok := t.stop(StatusCancelled, nil, true); ok {
+		t.record(data)
+	}
+}
+
+func (t *txn) finish(status Status, err error) {
+	if data, ok := t.stop(status, err, false); ok {
+		t.record(data)
+	}
+}
+
+// stop ends the transaction and returns its completion entry, ok is false when there is nothing to record.
+// The entry is recorded by the caller once t.mu is released, so the processors can log through the transaction.
+func (t *txn) stop(status Status, err error, shutdown bool) (data log.Entry, ok bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if !t.active {
+		return log.Entry{}, false
+	}
 	t.active = false
+	t.unwatch()
+
+	if !shutdown {
+		if t.cancelErr != nil && status == StatusOK {
+			status, err = StatusCancelled, t.cancelErr
+		}
+
+		if t.app != nil {
+			t.app.removeTransaction(t)
+			if t.app.closed.Load() {
+				return log.Entry{}, false
+			}
+		}
+	}
+
+	return t.complete(status, err)
 }
 
-func (t *txn) log(level log.Level, msg string, attributes ...log.Attrb) {
+// unwatch stops watching the contexts carrying the transaction. t.mu must be held.
+func (t *txn) unwatch() {
+	for _, stop := range t.stopWatching {
+		stop()
+	}
+	t.stopWatching = nil
+}
+
+// complete returns the transaction summary, ok is false when its level is filtered out. t.mu must be held.
+func (t *txn) complete(status Status, err error) (data log.Entry, ok bool) {
+	level := log.InfoLevel
+	switch status {
+	case StatusError:
+		level = log.ErrorLevel
+	case StatusCancelled:
+		level = log.WarnLevel
+	}
+
+	end := time.Now()
+	attributes := []log.Attrb{
+		log.String("status", string(status)),
+		log.Time("start_time", t.start),
+		log.Time("end_time", end),
+		log.Duration("duration", end.Sub(t.start)),
+		log.Any("entries", maps.Clone(t.counts)),
+	}
+	if err != nil {
+		attributes = append(attributes, log.Err(err))
+	}
+
+	data = log.Entry{
+		Timestamp:     end,
+		Level:         level,
+		AppName:       t.config.AppName,
+		Message:       "Transaction completed",
+		Attributes:    mergeAttributes(t.attr, attributes),
+		TransactionID: t.id,
+	}
+	return data, log.IsValid(t.threshold(data), level)
+}
+
+// watch cancels the transaction when ctx is cancelled before the transaction ends.
+func (t *txn) watch(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() {
+		t.cancel(context.Cause(ctx))
+	})
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	if !t.active {
-		t.drv.RecordLog(log.Entry{
+		stop()
+		return
+	}
+	t.stopWatching = append(t.stopWatching, stop)
+}
+
+// cancel records that the context carrying the transaction was cancelled.
+// It has no effect on a transaction that already ended.
+func (t *txn) cancel(cause error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.active && t.cancelErr == nil {
+		t.cancelErr = cause
+	}
+}
+
+// setAttributes adds attributes to the transaction, replacing the existing ones with the same key.
+func (t *txn) setAttributes(attributes ...log.Attrb) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attr = mergeAttributes(t.attr, attributes)
+}
+
+func (t *txn) log(level log.Level, msg string, attributes ...log.Attrb) {
+	t.emit(log.Entry{
+		Timestamp:  time.Now(),
+		Level:      level,
+		AppName:    t.config.AppName,
+		Message:    msg,
+		Attributes: attributes,
+		Source:     callerSource(t.config, 2),
+	})
+}
+
+// emit records data as part of the transaction, it is shared by the transaction and its spans.
+// An ended transaction records an error entry instead.
+// The processors run without t.mu held, so they can log through the transaction.
+func (t *txn) emit(data log.Entry) {
+	if t.app != nil && t.app.closed.Load() {
+		return
+	}
+
+	t.mu.Lock()
+	active := t.active
+	if active {
+		data.TransactionID = t.id
+		data.Attributes = mergeAttributes(t.attr, data.Attributes)
+	}
+	t.mu.Unlock()
+
+	if !active {
+		t.record(log.Entry{
 			Timestamp: time.Now(),
 			Level:     log.ErrorLevel,
 			AppName:   appName,
@@ -59,20 +225,50 @@ func (t *txn) log(level log.Level, msg string, attributes ...log.Attrb) {
 		return
 	}
 
-	if !log.IsValid(t.config.LogLevel, level) {
+	if !log.IsValid(t.threshold(data), data.Level) {
 		return
 	}
 
-	data := log.Entry{
-		Timestamp:     time.Now(),
-		Level:         level,
-		AppName:       t.config.AppName,
-		Message:       msg,
-		Attributes:    attributes,
-		TransactionID: t.id,
+	process(t.config, data, t.count)
+}
+
+// threshold returns the lowest level recorded by the transaction for the entry.
+func (t *txn) threshold(data log.Entry) log.Level {
+	if t.app != nil {
+		return t.app.threshold(data)
 	}
+	return matchLevel(t.config.LevelRules, data, t.level.Level())
+}
 
-	t.drv.RecordLog(data)
+func (t *txn) startSpan(parentID string, name string, attributes ...log.Attrb) *Span {
+	if parentID == "" {
+		parentID = t.id
+	}
+	return newSpan(t, parentID, name, attributes...)
+}
+
+// record passes data through the processors, the redaction and then to the driver.
+func (t *txn) record(data log.Entry) {
+	process(t.config, data, t.write)
+}
+
+// count adds an entry kept by the processors to the counts of the transaction summary, then writes it.
+func (t *txn) count(data log.Entry) {
+	t.mu.Lock()
+	t.counts[data.Level]++
+	t.mu.Unlock()
+
+	t.write(data)
+}
+
+// write redacts data and passes it to the driver.
+func (t *txn) write(data log.Entry) {
+	if t.redactor != nil {
+		data = t.redactor.redact(data)
+	}
+	if err := t.drv.RecordLog(data); err != nil {
+		reportError(t.config, fmt.Errorf("unable to record log entry: %w", err))
+	}
 }
 
 func generateID() (string, error) {
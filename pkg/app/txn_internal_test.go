@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -16,6 +17,8 @@ func TestTxnEnd(t *testing.T) {
 
 	txn := newPrivateTxn(driver, cfg)
 
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
 	assert.True(t, txn.active, "Transaction should be active when created")
 
 	txn.end()
@@ -39,7 +42,7 @@ func TestTxnLogWhenActive(t *testing.T) {
 		TransactionID: txn.id,
 	}
 
-	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return()
+	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return(nil)
 
 	txn.log(log.DebugLevel, "Test message", log.Attr("key", "value"))
 
@@ -58,6 +61,8 @@ func TestTxnLogWhenEnded(t *testing.T) {
 
 	txn := newPrivateTxn(driver, cfg)
 
+	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return(nil)
+
 	txn.end()
 
 	expectedEntry := log.Entry{
@@ -67,8 +72,6 @@ func TestTxnLogWhenEnded(t *testing.T) {
 		Message:   "Transaction already ended!",
 	}
 
-	driver.On("RecordLog", mock.AnythingOfType("log.Entry")).Return()
-
 	txn.log(log.DebugLevel, "This should not log")
 
 	driver.AssertCalled(t, "RecordLog", mock.MatchedBy(func(entry log.Entry) bool {
@@ -83,7 +86,7 @@ func TestTxnLogInvalidLogLevel(t *testing.T) {
 
 	txn := newPrivateTxn(driver, cfg)
 
-	driver.On("RecordLog", mock.Anything).Return()
+	driver.On("RecordLog", mock.Anything).Return(nil)
 
 	txn.log(log.DebugLevel, "This should not log")
 
@@ -99,3 +102,93 @@ func TestGenerateID(t *testing.T) {
 
 	assert.NotEqual(t, id1, id2, "Generated IDs should be unique")
 }
+
+func attributeMap(entry log.Entry) map[string]any {
+	attributes := map[string]any{}
+	for _, attr := range entry.Attributes {
+		attributes[attr.Key] = attr.Value.Any()
+	}
+	return attributes
+}
+
+func TestTxnEndSummary(t *testing.T) {
+	cfg := config.DefaultConfig()
+	driver := new(MockDriver)
+
+	txn := newPrivateTxn(driver, cfg, log.Attr("userID", "12345"))
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn.log(log.DebugLevel, "First")
+	txn.log(log.DebugLevel, "Second")
+	txn.log(log.WarnLevel, "Third")
+	txn.end()
+	txn.end()
+
+	driver.AssertNumberOfCalls(t, "RecordLog", 4)
+	summary := driver.Calls[3].Arguments.Get(0).(log.Entry)
+	attributes := attributeMap(summary)
+
+	assert.Equal(t, "Transaction completed", summary.Message)
+	assert.Equal(t, log.InfoLevel, summary.Level)
+	assert.Equal(t, txn.id, summary.TransactionID)
+	assert.Equal(t, string(StatusOK), attributes["status"])
+	assert.Equal(t, map[log.Level]int{log.DebugLevel: 2, log.WarnLevel: 1}, attributes["entries"])
+	assert.InDelta(t, attributes["end_time"].(time.Time).Sub(attributes["start_time"].(time.Time)), attributes["duration"], float64(time.Millisecond))
+	assert.Equal(t, "12345", attributes["userID"], "Creation attributes should be included")
+}
+
+func TestTxnEndWithError(t *testing.T) {
+	cfg := config.DefaultConfig()
+	driver := new(MockDriver)
+
+	txn := newPrivateTxn(driver, cfg)
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn.endWithError(errors.New("payment declined"))
+
+	summary := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	attributes := attributeMap(summary)
+
+	assert.False(t, txn.active)
+	assert.Equal(t, log.ErrorLevel, summary.Level)
+	assert.Equal(t, string(StatusError), attributes["status"])
+	assert.EqualError(t, attributes["error"].(error), "payment declined")
+}
+
+func TestTxnLogMergesAttributes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	driver := new(MockDriver)
+
+	txn := newPrivateTxn(driver, cfg, log.Attr("userID", "12345"), log.Attr("requestID", "abc"))
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn.log(log.InfoLevel, "Test message", log.Attr("requestID", "override"), log.Attr("key", "value"))
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{
+		log.Attr("userID", "12345"),
+		log.Attr("requestID", "override"),
+		log.Attr("key", "value"),
+	}, entry.Attributes, "Entry attributes should take precedence over transaction attributes")
+}
+
+func TestTxnSetAttributes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	driver := new(MockDriver)
+
+	txn := newPrivateTxn(driver, cfg, log.Attr("userID", "unknown"))
+
+	driver.On("RecordLog", mock.Anything).Return(nil)
+
+	txn.setAttributes(log.Attr("userID", "12345"), log.Attr("tenant", "acme"))
+	txn.log(log.InfoLevel, "Test message")
+
+	entry := driver.Calls[0].Arguments.Get(0).(log.Entry)
+	assert.Equal(t, []log.Attrb{
+		log.Attr("userID", "12345"),
+		log.Attr("tenant", "acme"),
+	}, entry.Attributes)
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"regexp"
+	"time"
 
 	"github.com/ralugr/datacollector/pkg/log"
 )
@@ -9,10 +12,38 @@ import (
 type Config struct {
 	AppName  string
 	LogLevel log.Level
+	// LevelVar, when set, holds the log level shared with other applications.
+	// Otherwise each application creates its own LevelVar from LogLevel.
+	LevelVar *log.LevelVar
+	// LevelRules override the log level for specific loggers or attribute values.
+	LevelRules []LevelRule
 	// Error may be populated by the ConfigOptions provided to NewApplication
 	// to indicate that setup has failed.  NewApplication will return this
 	// error if it is set.
 	Error error
+	// ErrorHandler is called with the errors reported by the driver.
+	// When nil, the errors are printed to stderr.
+	ErrorHandler func(err error)
+	// HandleSignals makes the application shut down gracefully when it receives
+	// one of the ShutdownSignals (SIGINT and SIGTERM by default).
+	HandleSignals   bool
+	ShutdownSignals []os.Signal
+	// ShutdownTimeout bounds the shutdown started by a signal or by App.Fatal.
+	ShutdownTimeout time.Duration
+	// FatalExitCode is the exit code used by App.Fatal.
+	FatalExitCode int
+	// AddSource records the file, line and function of the code that logged each entry.
+	AddSource bool
+	// CallerSkip is the number of additional stack frames to skip when recording the source,
+	// for helpers that wrap the logging methods.
+	CallerSkip int
+	// RedactKeys, RedactPatterns and RedactionMode configure how sensitive values are redacted
+	// before the entries reach the driver, see RedactKeys and RedactPatterns.
+	RedactKeys     []string
+	RedactPatterns []*regexp.Regexp
+	RedactionMode  RedactionMode
+	// Processors transform the entries before they are redacted and recorded, see Processors.
+	Processors []log.Processor
 }
 
 type ConfigOption func(*Config)
@@ -21,6 +52,8 @@ func AppName(appName string) ConfigOption {
 	return func(cfg *Config) { cfg.AppName = appName }
 }
 
+// LogLevel sets the initial level of the application. It is ignored when a shared LevelVar is used,
+// so it never changes the level of the other applications sharing it.
 func LogLevel(logLevel log.Level) ConfigOption {
 	return func(cfg *Config) {
 		if !validInput(logLevel) {
@@ -31,21 +64,96 @@ func LogLevel(logLevel log.Level) ConfigOption {
 	}
 }
 
+// LevelVar makes the application use a shared level that can be changed at runtime.
+// The application starts at the current level of levelVar, whatever the order of the options.
+func LevelVar(levelVar *log.LevelVar) ConfigOption {
+	return func(cfg *Config) {
+		if levelVar == nil {
+			cfg.Error = fmt.Errorf("Invalid value: %v", levelVar)
+			return
+		}
+		cfg.LevelVar = levelVar
+		cfg.LogLevel = levelVar.Level()
+	}
+}
+
+// ErrorHandler sets the callback used to report driver errors.
+func ErrorHandler(handler func(err error)) ConfigOption {
+	return func(cfg *Config) { cfg.ErrorHandler = handler }
+}
+
+// ShutdownOnSignal shuts the application down when one of the signals is received and then exits the process.
+// SIGINT and SIGTERM are used when no signals are given. The shutdown is bounded by ShutdownTimeout.
+func ShutdownOnSignal(signals ...os.Signal) ConfigOption {
+	return func(cfg *Config) {
+		cfg.HandleSignals = true
+		cfg.ShutdownSignals = signals
+	}
+}
+
+// ShutdownTimeout sets how long the shutdown started by a signal or by App.Fatal may take
+// before it is abandoned, the default is 5 seconds.
+func ShutdownTimeout(timeout time.Duration) ConfigOption {
+	return func(cfg *Config) {
+		if timeout <= 0 {
+			cfg.Error = fmt.Errorf("Invalid value: %v", timeout)
+			return
+		}
+		cfg.ShutdownTimeout = timeout
+	}
+}
+
+// FatalExitCode sets the exit code used by App.Fatal.
+func FatalExitCode(code int) ConfigOption {
+	return func(cfg *Config) { cfg.FatalExitCode = code }
+}
+
+// AddSource records the location of the code that logged each entry in log.Entry.Source.
+func AddSource() ConfigOption {
+	return func(cfg *Config) { cfg.AddSource = true }
+}
+
+// CallerSkip skips skip more stack frames when recording the source, so that the location of the caller of
+// a logging helper is recorded instead of the helper itself. It has no effect without AddSource.
+func CallerSkip(skip int) ConfigOption {
+	return func(cfg *Config) {
+		if skip < 0 {
+			cfg.Error = fmt.Errorf("Invalid value: %v", skip)
+			return
+		}
+		cfg.CallerSkip = skip
+	}
+}
+
+// Processors adds processors that transform, drop or split the entries of the App and of its transactions.
+// The pipeline of an entry is: level filter, the processors in the order they were added, redaction, driver.
+// The entries returned by the processors are not filtered by level again.
+// A processor that panics is skipped for that entry, and the panic is reported through the ErrorHandler.
+func Processors(processors ...log.Processor) ConfigOption {
+	return func(cfg *Config) {
+		for _, processor := range processors {
+			if processor == nil {
+				cfg.Error = fmt.Errorf("Invalid value: %v", processor)
+				return
+			}
+		}
+		cfg.Processors = append(cfg.Processors, processors...)
+	}
+}
+
 func DefaultConfig() Config {
 	c := Config{}
 
 	c.AppName = "Test App"
 	c.LogLevel = log.DebugLevel
+	c.ShutdownTimeout = 5 * time.Second
+	c.FatalExitCode = 1
 	c.Error = nil
 
 	return c
 }
 
+// validInput accepts the predefined levels and the levels added with log.RegisterLevel.
 func validInput(level log.Level) bool {
-	switch level {
-	case log.DebugLevel, log.InfoLevel, log.WarnLevel, log.ErrorLevel:
-		return true
-	default:
-		return false
-	}
+	return log.IsKnown(level)
 }
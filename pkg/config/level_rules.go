@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// LoggerKey is the LevelRule key that matches the logger name of an entry instead of an attribute.
+const LoggerKey = "logger"
+
+// LevelRule overrides the log level for the entries that match it.
+// A rule matches the entries with an attribute named Key whose value, formatted with fmt.Sprint, is Value.
+// Attributes inside groups are named with dotted keys, e.g. "http.method".
+// A rule with the LoggerKey matches the entries of the logger named Value and of its children,
+// e.g. "http" matches "http" and "http.client".
+//
+// Rules are evaluated in order before the entry is passed to the driver, the first matching rule
+// decides the level. The application level applies when no rule matches.
+type LevelRule struct {
+	Key   string    `json:"key"`
+	Value string    `json:"value"`
+	Level log.Level `json:"level"`
+}
+
+// Matches reports whether the rule applies to the entry.
+func (r LevelRule) Matches(entry log.Entry) bool {
+	if r.Key == LoggerKey {
+		return entry.Logger == r.Value || strings.HasPrefix(entry.Logger, r.Value+".")
+	}
+
+	for _, attr := range log.Flatten(entry.Attributes) {
+		if attr.Key == r.Key && fmt.Sprint(attr.Value) == r.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the rule has no key or an unknown level.
+func (r LevelRule) Validate() error {
+	if r.Key == "" {
+		return fmt.Errorf("level rule key is required")
+	}
+	if !validInput(r.Level) {
+		return fmt.Errorf("Invalid value: %v", r.Level)
+	}
+	return nil
+}
+
+// LevelRules sets the rules that override the log level, see LevelRule.
+func LevelRules(rules ...LevelRule) ConfigOption {
+	return func(cfg *Config) {
+		for _, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				cfg.Error = err
+				return
+			}
+		}
+		cfg.LevelRules = rules
+	}
+}
+
+// LevelRulesFile loads the rules that override the log level from a JSON file, e.g.
+//
+//	[
+//	  {"key": "logger", "value": "db", "level": "DEBUG"},
+//	  {"key": "tenant", "value": "acme", "level": "DEBUG"}
+//	]
+func LevelRulesFile(fileName string) ConfigOption {
+	return func(cfg *Config) {
+		rules, err := LoadLevelRules(fileName)
+		if err != nil {
+			cfg.Error = err
+			return
+		}
+		LevelRules(rules...)(cfg)
+	}
+}
+
+// LoadLevelRules reads the rules from a JSON file, see LevelRulesFile.
+func LoadLevelRules(fileName string) ([]LevelRule, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read level rules: %w", err)
+	}
+
+	var rules []LevelRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("unable to parse level rules from %v: %w", fileName, err)
+	}
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
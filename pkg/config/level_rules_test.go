@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelRuleMatches(t *testing.T) {
+	tests := []struct {
+		rule     LevelRule
+		entry    log.Entry
+		expected bool
+	}{
+		{LevelRule{Key: LoggerKey, Value: "db"}, log.Entry{Logger: "db"}, true},
+		{LevelRule{Key: LoggerKey, Value: "db"}, log.Entry{Logger: "db.pool"}, true},
+		{LevelRule{Key: LoggerKey, Value: "db"}, log.Entry{Logger: "dbx"}, false},
+		{LevelRule{Key: LoggerKey, Value: "db"}, log.Entry{}, false},
+		{LevelRule{Key: "tenant", Value: "acme"}, log.Entry{Attributes: []log.Attrb{log.Attr("tenant", "acme")}}, true},
+		{LevelRule{Key: "attempt", Value: "3"}, log.Entry{Attributes: []log.Attrb{log.Attr("attempt", 3)}}, true},
+		{LevelRule{Key: "tenant", Value: "acme"}, log.Entry{Attributes: []log.Attrb{log.Attr("tenant", "other")}}, false},
+		{LevelRule{Key: "http.method", Value: "GET"}, log.Entry{Attributes: []log.Attrb{log.Group("http", log.String("method", "GET"))}}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.rule.Matches(tt.entry), "rule %+v, entry %+v", tt.rule, tt.entry)
+	}
+}
+
+func TestLevelRules(t *testing.T) {
+	rules := []LevelRule{
+		{Key: LoggerKey, Value: "db", Level: log.DebugLevel},
+		{Key: "tenant", Value: "acme", Level: log.DebugLevel},
+	}
+
+	cfg := DefaultConfig()
+	LevelRules(rules...)(&cfg)
+
+	assert.Nil(t, cfg.Error)
+	assert.Equal(t, rules, cfg.LevelRules)
+}
+
+func TestLevelRulesInvalidInput(t *testing.T) {
+	cfg := DefaultConfig()
+	LevelRules(LevelRule{Key: LoggerKey, Value: "db", Level: "LOUD"})(&cfg)
+	assert.EqualError(t, cfg.Error, "Invalid value: LOUD")
+
+	cfg = DefaultConfig()
+	LevelRules(LevelRule{Value: "db", Level: log.DebugLevel})(&cfg)
+	assert.Error(t, cfg.Error)
+	assert.Empty(t, cfg.LevelRules)
+}
+
+func TestLevelRulesFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rules.json")
+	content := `[{"key": "logger", "value": "http", "level": "WARNING"}, {"key": "tenant", "value": "acme", "level": "DEBUG"}]`
+	assert.NoError(t, os.WriteFile(fileName, []byte(content), 0644))
+
+	cfg := DefaultConfig()
+	LevelRulesFile(fileName)(&cfg)
+
+	assert.Nil(t, cfg.Error)
+	assert.Equal(t, []LevelRule{
+		{Key: LoggerKey, Value: "http", Level: log.WarnLevel},
+		{Key: "tenant", Value: "acme", Level: log.DebugLevel},
+	}, cfg.LevelRules)
+
+	cfg = DefaultConfig()
+	LevelRulesFile(filepath.Join(t.TempDir(), "missing.json"))(&cfg)
+	assert.Error(t, cfg.Error)
+}
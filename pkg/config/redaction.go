@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RedactionMode defines how the sensitive values are replaced before the entries reach the driver.
+type RedactionMode int
+
+const (
+	// MaskRedaction replaces the sensitive values with log.RedactionMask.
+	MaskRedaction RedactionMode = iota
+	// HashRedaction replaces the sensitive values with a SHA-256 hash prefix, so equal values can still be correlated.
+	HashRedaction
+)
+
+// DefaultRedactKeys are attribute keys that usually hold secrets, they can be passed to RedactKeys.
+var DefaultRedactKeys = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "authorization", "cookie", "set-cookie",
+}
+
+// Patterns for common sensitive values, they can be passed to RedactPatterns.
+const (
+	EmailPattern      = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+	CardNumberPattern = `\b(?:\d[ -]?){12,18}\d\b`
+	JWTPattern        = `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`
+)
+
+// RedactKeys redacts the values of the attributes with one of the keys, at any group level.
+// Keys are compared without case, e.g. "Authorization" matches "authorization".
+func RedactKeys(keys ...string) ConfigOption {
+	return func(cfg *Config) {
+		for _, key := range keys {
+			if key == "" {
+				cfg.Error = fmt.Errorf("Invalid value: %q", key)
+				return
+			}
+		}
+		cfg.RedactKeys = append(cfg.RedactKeys, keys...)
+	}
+}
+
+// RedactPatterns redacts the parts of the messages and of the string attribute values that match one of the regular expressions.
+func RedactPatterns(patterns ...string) ConfigOption {
+	return func(cfg *Config) {
+		compiled := make([]*regexp.Regexp, 0, len(patterns))
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				cfg.Error = fmt.Errorf("Invalid value: %w", err)
+				return
+			}
+			compiled = append(compiled, re)
+		}
+		cfg.RedactPatterns = append(cfg.RedactPatterns, compiled...)
+	}
+}
+
+// Redaction sets how the sensitive values are replaced, MaskRedaction is the default.
+func Redaction(mode RedactionMode) ConfigOption {
+	return func(cfg *Config) {
+		if mode != MaskRedaction && mode != HashRedaction {
+			cfg.Error = fmt.Errorf("Invalid value: %v", mode)
+			return
+		}
+		cfg.RedactionMode = mode
+	}
+}
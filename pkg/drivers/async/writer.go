@@ -0,0 +1,258 @@
+package async
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ralugr/datacollector/pkg/app"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// DefaultQueueSize is the number of entries a Writer buffers when QueueSize is not used.
+const DefaultQueueSize = 1024
+
+// OverflowPolicy decides what RecordLog does when the queue is full.
+type OverflowPolicy int
+
+const (
+	// Block waits until the background writer frees a slot in the queue.
+	Block OverflowPolicy = iota
+	// DropNewest discards the entry being recorded.
+	DropNewest
+	// DropOldest discards the oldest queued entry to make room for the new one.
+	DropOldest
+)
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// QueueSize sets the maximum number of entries waiting to be written.
+func QueueSize(size int) Option {
+	return func(w *Writer) { w.size = size }
+}
+
+// Overflow sets the policy applied when the queue is full.
+func Overflow(policy OverflowPolicy) Option {
+	return func(w *Writer) { w.policy = policy }
+}
+
+// ErrorHandler sets the callback used to report the errors returned by the wrapped driver
+// while writing in the background. When not set, the errors are printed to stderr.
+func ErrorHandler(handler func(err error)) Option {
+	return func(w *Writer) { w.onError = handler }
+}
+
+// Writer is a driver that queues the entries and writes them to another driver from a
+// background goroutine, so logging never waits for a slow output unless the Block policy
+// is used and the queue is full.
+type Writer struct {
+	next    app.Driver
+	size    int
+	policy  OverflowPolicy
+	onError func(err error)
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	progress *sync.Cond
+	// queue is a ring buffer holding count entries starting at head.
+	queue []log.Entry
+	head  int
+	count int
+	// accepted and handled count the entries added to and removed from the queue,
+	// they are compared by Flush to wait for the entries recorded before it.
+	accepted uint64
+	handled  uint64
+	closed   bool
+
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+// NewWriter wraps driver and starts the background writer goroutine.
+// Close must be called to drain the queue and stop the goroutine.
+func NewWriter(driver app.Driver, opts ...Option) (*Writer, error) {
+	if driver == nil {
+		return nil, fmt.Errorf("a driver is required")
+	}
+
+	w := &Writer{
+		next:   driver,
+		size:   DefaultQueueSize,
+		policy: Block,
+		done:   make(chan struct{}),
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+
+	if w.size <= 0 {
+		return nil, fmt.Errorf("invalid queue size: %v", w.size)
+	}
+	if w.policy != Block && w.policy != DropNewest && w.policy != DropOldest {
+		return nil, fmt.Errorf("invalid overflow policy: %v", w.policy)
+	}
+
+	w.queue = make([]log.Entry, w.size)
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+	w.progress = sync.NewCond(&w.mu)
+
+	go w.run()
+
+	return w, nil
+}
+
+// Dropped returns the number of entries discarded because the queue was full.
+func (w *Writer) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// SetEncoding sets the encoding of the wrapped driver.
+func (w *Writer) SetEncoding(encoding string) error {
+	return w.next.SetEncoding(encoding)
+}
+
+// RecordLog queues the entry. The error returned by the wrapped driver is reported
+// through the error handler since the entry is written later.
+func (w *Writer) RecordLog(logInfo log.Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.count == w.size && !w.closed {
+		switch w.policy {
+		case DropNewest:
+			w.dropped.Add(1)
+			return nil
+		case DropOldest:
+			w.pop()
+			w.handled++
+			w.dropped.Add(1)
+			w.progress.Broadcast()
+		default:
+			w.notFull.Wait()
+		}
+	}
+
+	if w.closed {
+		return fmt.Errorf("async writer is closed")
+	}
+
+	w.queue[(w.head+w.count)%w.size] = logInfo
+	w.count++
+	w.accepted++
+	w.notEmpty.Signal()
+
+	return nil
+}
+
+// Flush waits until the entries recorded before the call are written and then flushes the wrapped driver.
+func (w *Writer) Flush(ctx context.Context) error {
+	if err := w.wait(ctx); err != nil {
+		return err
+	}
+	return w.next.Flush(ctx)
+}
+
+// Sync waits until the entries recorded before the call are written and then syncs the wrapped driver,
+// which is flushed if it does not implement app.Syncer.
+func (w *Writer) Sync(ctx context.Context) error {
+	if err := w.wait(ctx); err != nil {
+		return err
+	}
+	return app.SyncDriver(ctx, w.next)
+}
+
+// Close stops accepting entries, waits for the queue to be drained and closes the wrapped driver.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		return fmt.Errorf("async writer was not drained: %w", ctx.Err())
+	}
+
+	return w.next.Close(ctx)
+}
+
+// wait blocks until every entry accepted so far has been handled or ctx is done.
+func (w *Writer) wait(ctx context.Context) error {
+	// The waiter is woken up when ctx is done, so it does not outlive the call.
+	stop := context.AfterFunc(ctx, func() {
+		w.mu.Lock()
+		w.progress.Broadcast()
+		w.mu.Unlock()
+	})
+	defer stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	target := w.accepted
+	for w.handled < target {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("async writer was not drained: %w", err)
+		}
+		w.progress.Wait()
+	}
+	return nil
+}
+
+// run writes the queued entries until the writer is closed and the queue is empty.
+func (w *Writer) run() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for w.count == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+		if w.count == 0 {
+			w.mu.Unlock()
+			return
+		}
+		entry := w.pop()
+		w.notFull.Signal()
+		w.mu.Unlock()
+
+		err := w.next.RecordLog(entry)
+
+		w.mu.Lock()
+		w.handled++
+		w.progress.Broadcast()
+		w.mu.Unlock()
+
+		if err != nil {
+			w.reportError(fmt.Errorf("unable to record log entry: %w", err))
+		}
+	}
+}
+
+// pop removes the oldest entry from the queue. w.mu must be held.
+func (w *Writer) pop() log.Entry {
+	entry := w.queue[w.head]
+	w.queue[w.head] = log.Entry{}
+	w.head = (w.head + 1) % w.size
+	w.count--
+	return entry
+}
+
+func (w *Writer) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "async writer: %v\n", err)
+}
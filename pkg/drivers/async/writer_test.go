@@ -0,0 +1,253 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver records the entries once release is closed, if set.
+type fakeDriver struct {
+	mu      sync.Mutex
+	entries []log.Entry
+	release chan struct{}
+	err     error
+	flushed bool
+	closed  bool
+}
+
+func (d *fakeDriver) RecordLog(logInfo log.Entry) error {
+	if d.release != nil {
+		<-d.release
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(d.entries, logInfo)
+	return d.err
+}
+
+func (d *fakeDriver) SetEncoding(encoding string) error {
+	return nil
+}
+
+func (d *fakeDriver) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flushed = true
+	return nil
+}
+
+func (d *fakeDriver) Close(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+type syncingDriver struct {
+	fakeDriver
+	synced bool
+}
+
+func (d *syncingDriver) Sync(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.synced = true
+	return nil
+}
+
+func (d *fakeDriver) messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var messages []string
+	for _, e := range d.entries {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+// queueLen returns the number of entries waiting in the queue.
+func (w *Writer) queueLen() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+func TestNewWriterInvalidInput(t *testing.T) {
+	_, err := NewWriter(nil)
+	assert.Error(t, err)
+
+	_, err = NewWriter(&fakeDriver{}, QueueSize(0))
+	assert.Error(t, err)
+
+	_, err = NewWriter(&fakeDriver{}, Overflow(OverflowPolicy(42)))
+	assert.Error(t, err)
+}
+
+func TestCloseDrainsQueue(t *testing.T) {
+	driver := &fakeDriver{}
+	writer, err := NewWriter(driver)
+	assert.NoError(t, err)
+
+	for _, msg := range []string{"first", "second", "third"} {
+		assert.NoError(t, writer.RecordLog(log.Entry{Message: msg}))
+	}
+
+	assert.NoError(t, writer.Close(context.Background()))
+
+	assert.Equal(t, []string{"first", "second", "third"}, driver.messages())
+	assert.True(t, driver.closed)
+	assert.Error(t, writer.RecordLog(log.Entry{Message: "late"}), "Closed writer should reject entries")
+}
+
+func TestFlushWaitsForQueuedEntries(t *testing.T) {
+	driver := &fakeDriver{release: make(chan struct{})}
+	writer, err := NewWriter(driver)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, writer.Flush(ctx), context.DeadlineExceeded, "Flush should wait for the slow driver")
+
+	close(driver.release)
+	assert.NoError(t, writer.Flush(context.Background()))
+	assert.Equal(t, []string{"first"}, driver.messages())
+	assert.True(t, driver.flushed)
+}
+
+func TestFlushTimeoutDoesNotLeakGoroutines(t *testing.T) {
+	driver := &fakeDriver{release: make(chan struct{})}
+	writer, err := NewWriter(driver)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	defer close(driver.release)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+
+	before := runtime.NumGoroutine()
+	for range 10 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		assert.ErrorIs(t, writer.Flush(ctx), context.DeadlineExceeded)
+		cancel()
+	}
+	// assert.Eventually runs the condition in a goroutine of its own, so the count is polled here.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "The waiters should stop once their context is done")
+}
+
+func TestSyncWaitsForQueuedEntries(t *testing.T) {
+	driver := &syncingDriver{fakeDriver: fakeDriver{release: make(chan struct{})}}
+	writer, err := NewWriter(driver)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, writer.Sync(ctx), context.DeadlineExceeded, "Sync should wait for the slow driver")
+
+	close(driver.release)
+	assert.NoError(t, writer.Sync(context.Background()))
+	assert.Equal(t, []string{"first"}, driver.messages())
+	assert.True(t, driver.synced)
+	assert.False(t, driver.flushed, "Driver implementing Sync should not be flushed")
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	driver := &fakeDriver{release: make(chan struct{})}
+	writer, err := NewWriter(driver, QueueSize(1), Overflow(DropNewest))
+	assert.NoError(t, err)
+
+	// The first entry is picked up by the blocked background writer, the second fills the queue.
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+	assert.Eventually(t, func() bool { return writer.queueLen() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "second"}))
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "third"}))
+
+	close(driver.release)
+	assert.NoError(t, writer.Close(context.Background()))
+
+	assert.Equal(t, []string{"first", "second"}, driver.messages())
+	assert.Equal(t, uint64(1), writer.Dropped())
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	driver := &fakeDriver{release: make(chan struct{})}
+	writer, err := NewWriter(driver, QueueSize(1), Overflow(DropOldest))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+	assert.Eventually(t, func() bool { return writer.queueLen() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "second"}))
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "third"}))
+
+	close(driver.release)
+	assert.NoError(t, writer.Close(context.Background()))
+
+	assert.Equal(t, []string{"first", "third"}, driver.messages())
+	assert.Equal(t, uint64(1), writer.Dropped())
+}
+
+func TestOverflowBlock(t *testing.T) {
+	driver := &fakeDriver{release: make(chan struct{})}
+	writer, err := NewWriter(driver, QueueSize(1))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+	assert.Eventually(t, func() bool { return writer.queueLen() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "second"}))
+
+	recorded := make(chan struct{})
+	go func() {
+		writer.RecordLog(log.Entry{Message: "third"})
+		close(recorded)
+	}()
+
+	select {
+	case <-recorded:
+		t.Fatal("RecordLog should block while the queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(driver.release)
+	<-recorded
+	assert.NoError(t, writer.Close(context.Background()))
+
+	assert.Equal(t, []string{"first", "second", "third"}, driver.messages())
+	assert.Zero(t, writer.Dropped())
+}
+
+func TestBackgroundErrorsAreReported(t *testing.T) {
+	driver := &fakeDriver{err: errors.New("disk full")}
+
+	var mu sync.Mutex
+	var reported []error
+	writer, err := NewWriter(driver, ErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Message: "first"}))
+	assert.NoError(t, writer.Close(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], driver.err)
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -16,54 +18,108 @@ const (
 
 // supports json and plain text
 type Writer struct {
-	encoding string
-	mu       sync.Mutex
+	encoding   string
+	duplicates log.DuplicateKeys
+	mu         sync.Mutex
 }
 
-func NewWriter() *Writer {
-	return &Writer{
+// Option configures a Writer.
+type Option func(*Writer)
+
+// DuplicateKeys sets how attributes with the same key are written with the json encoding, the default is log.KeepLast.
+func DuplicateKeys(policy log.DuplicateKeys) Option {
+	return func(w *Writer) {
+		w.duplicates = policy
+	}
+}
+
+func NewWriter(opts ...Option) *Writer {
+	w := &Writer{
 		encoding: PlainEncoding,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
-func (w *Writer) SetEncoding(encoding string) {
+func (w *Writer) SetEncoding(encoding string) error {
 	if encoding != PlainEncoding && encoding != JSONEncoding {
-		return // thow an error?
+		return fmt.Errorf("unknown encoding %v", encoding)
 	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.encoding = encoding
+	return nil
 }
 
-func (w *Writer) RecordLog(logInfo log.Entry) {
+func (w *Writer) RecordLog(logInfo log.Entry) error {
+	var line string
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	switch w.encoding {
-	case PlainEncoding:
-		fmt.Println(w.logEntryToString(logInfo))
 	case JSONEncoding:
-		fmt.Println(w.logEntryToJson(logInfo))
+		jsonLine, err := w.logEntryToJson(logInfo)
+		if err != nil {
+			return err
+		}
+		line = jsonLine
 	default:
-		fmt.Println("Unknown encoding")
+		// The zero value Writer uses plain text.
+		line = w.logEntryToString(logInfo)
+	}
+
+	if _, err := fmt.Println(line); err != nil {
+		return fmt.Errorf("error writing to console: %w", err)
 	}
+	return nil
 }
 
-func (w *Writer) logEntryToString(log log.Entry) string {
-	s := fmt.Sprintf("time:%v, level:%v, app_name:%v, message:%v, attributes:%v", log.Timestamp.UTC().Format(time.RFC3339), log.Level,
-		log.AppName, log.Message, log.Attributes)
+// Flush has nothing to do since the console output is not buffered.
+func (w *Writer) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Close has nothing to do since the console output is not owned by the writer.
+func (w *Writer) Close(ctx context.Context) error {
+	return nil
+}
+
+func (w *Writer) logEntryToString(entry log.Entry) string {
+	s := fmt.Sprintf("time:%v, level:%v, app_name:%v, message:%v, attributes:%v", entry.Timestamp.UTC().Format(time.RFC3339), entry.Level,
+		entry.AppName, entry.Message, log.Flatten(entry.Attributes))
+
+	if entry.Logger != "" {
+		s += fmt.Sprintf(" logger:%v", entry.Logger)
+	}
 
-	if log.TransactionID != "" {
-		s += fmt.Sprintf(" transaction_id:%v", log.TransactionID)
+	if entry.TransactionID != "" {
+		s += fmt.Sprintf(" transaction_id:%v", entry.TransactionID)
+	}
+
+	if entry.SpanID != "" {
+		s += fmt.Sprintf(" span_id:%v parent_span_id:%v", entry.SpanID, entry.ParentSpanID)
+	}
+
+	if entry.Source != nil {
+		s += fmt.Sprintf(" source:%v", entry.Source)
 	}
 
 	return s
 }
 
-func (w *Writer) logEntryToJson(log log.Entry) string {
-	jsonData, err := json.MarshalIndent(log, "", "  ")
+func (w *Writer) logEntryToJson(entry log.Entry) (string, error) {
+	jsonData, err := log.MarshalEntry(entry, w.duplicates)
 	if err != nil {
-		fmt.Println("Error:", err) // throw an error?
-		return ""
+		return "", fmt.Errorf("unable to marshal %v: %w", entry, err)
 	}
 
-	return string(jsonData)
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, jsonData, "", "  "); err != nil {
+		return "", fmt.Errorf("unable to marshal %v: %w", entry, err)
+	}
+	return indented.String(), nil
 }
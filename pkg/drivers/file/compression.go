@@ -0,0 +1,209 @@
+package file
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tempSuffix is appended to the compressed backups until they are complete.
+const tempSuffix = ".tmp"
+
+// Compressor compresses the rotated files, see Compress.
+type Compressor interface {
+	// Extension is appended to the names of the compressed backups, e.g. ".gz".
+	Extension() string
+	// Compress writes the compressed content of src to dst.
+	Compress(dst io.Writer, src io.Reader) error
+}
+
+// The predefined compressors.
+var (
+	Gzip Compressor = gzipCompressor{}
+	Zstd Compressor = zstdCompressor{}
+)
+
+// compressionExtensions are recognized as compressed backups, even when another compressor is configured.
+var compressionExtensions = []string{".gz", ".zst"}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Extension() string {
+	return ".gz"
+}
+
+func (gzipCompressor) Compress(dst io.Writer, src io.Reader) error {
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) Extension() string {
+	return ".zst"
+}
+
+func (zstdCompressor) Compress(dst io.Writer, src io.Reader) error {
+	zw, err := zstd.NewWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// Compress compresses the rotated files in the background, e.g. file.Compress(file.Gzip).
+// The backup is first written to a temporary file that replaces the original only once it is complete,
+// so an interrupted compression never loses entries. The temporary files left by a crash are removed
+// and the backups that were not compressed yet are compressed when the next Writer starts.
+func Compress(compressor Compressor) Option {
+	return func(w *Writer) { w.compressor = compressor }
+}
+
+// ErrorHandler sets the callback used to report the errors of the background compression.
+// When not set, the errors are printed to stderr.
+func ErrorHandler(handler func(err error)) Option {
+	return func(w *Writer) { w.onError = handler }
+}
+
+// compressBackup compresses path in the background.
+func (w *Writer) compressBackup(path string) {
+	w.compressions.Add(1)
+	go func() {
+		defer w.compressions.Done()
+
+		// One file is compressed at a time, so a burst of rotations does not use all the CPUs.
+		w.compressMu.Lock()
+		defer w.compressMu.Unlock()
+
+		if err := compressFile(w.compressor, path); err != nil {
+			w.reportError(fmt.Errorf("failed to compress log backup %v: %w", path, err))
+		}
+	}()
+}
+
+// compressFile writes the compressed content of path to a temporary file, syncs it, renames it to its
+// final name and then removes path.
+func compressFile(compressor Compressor, path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The backup was deleted by the retention options in the meantime.
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+
+	target := path + compressor.Extension()
+	temp := target + tempSuffix
+	dst, err := os.OpenFile(temp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(temp)
+		}
+	}()
+
+	if err := compressor.Compress(dst, src); err != nil {
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, target); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(target))
+
+	return os.Remove(path)
+}
+
+// syncDir makes the renames in dir durable, it is best effort since not every platform supports it.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// recoverCompression removes the temporary files left by an interrupted compression and compresses
+// the backups that were not compressed yet. It is called when the Writer starts.
+func (w *Writer) recoverCompression() error {
+	dir := filepath.Dir(w.fileName)
+	prefix := filepath.Base(w.fileName) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list log backups: %w", err)
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suffix := strings.TrimPrefix(name, prefix)
+		path := filepath.Join(dir, name)
+
+		switch {
+		case strings.HasSuffix(suffix, tempSuffix):
+			// The temporary files are named after the compressed backup, e.g. app.log.<time>.gz.tmp.
+			original, _ := trimCompressionExtension(strings.TrimSuffix(suffix, tempSuffix))
+			if _, ok := parseBackupTime(original); !ok {
+				continue
+			}
+			// The compressed file is incomplete, the original is still there.
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove temporary log backup: %w", err))
+			}
+		case w.compressor == nil:
+		case hasCompressedCopy(names, name):
+			if _, ok := parseBackupTime(suffix); !ok {
+				continue
+			}
+			// The compression completed but the original was not removed yet.
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("failed to remove log backup: %w", err))
+			}
+		default:
+			if _, ok := parseBackupTime(suffix); ok {
+				w.compressBackup(path)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Writer) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "file writer: %v\n", err)
+}
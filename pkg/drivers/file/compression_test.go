@@ -0,0 +1,172 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func decompress(t *testing.T, compressor Compressor, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer f.Close()
+
+	var r io.Reader
+	switch compressor {
+	case Gzip:
+		zr, err := gzip.NewReader(f)
+		assert.NoError(t, err)
+		r = zr
+	case Zstd:
+		zr, err := zstd.NewReader(f)
+		assert.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	}
+	content, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestCompressRotatedFiles(t *testing.T) {
+	for _, compressor := range []Compressor{Gzip, Zstd} {
+		t.Run(compressor.Extension(), func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+			writer, err := NewWriter(tmpFile, MaxSize(1), Compress(compressor))
+			assert.NoError(t, err)
+			assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "rotated"}))
+			assert.NoError(t, writer.Close(context.Background()))
+
+			backups, err := writer.backups()
+			assert.NoError(t, err)
+			if assert.Len(t, backups, 1) {
+				assert.True(t, backups[0].compressed)
+				assert.Equal(t, compressor.Extension(), filepath.Ext(backups[0].path))
+				assert.Contains(t, decompress(t, compressor, backups[0].path), "message:rotated")
+				assert.NoFileExists(t, backups[0].path[:len(backups[0].path)-len(compressor.Extension())], "The original should be removed")
+			}
+			matches, _ := filepath.Glob(tmpFile + ".*" + tempSuffix)
+			assert.Empty(t, matches)
+		})
+	}
+}
+
+type failingCompressor struct{}
+
+func (failingCompressor) Extension() string {
+	return ".gz"
+}
+
+func (failingCompressor) Compress(dst io.Writer, src io.Reader) error {
+	dst.Write([]byte("partial"))
+	return errors.New("disk full")
+}
+
+func TestCompressFailureKeepsOriginal(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	var reported []error
+
+	writer, err := NewWriter(tmpFile, MaxSize(1), Compress(failingCompressor{}),
+		ErrorHandler(func(err error) { reported = append(reported, err) }))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "rotated"}))
+	assert.NoError(t, writer.Close(context.Background()))
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		assert.False(t, backups[0].compressed, "A failed compression should not replace the original")
+		content, err := os.ReadFile(backups[0].path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "message:rotated")
+	}
+	matches, _ := filepath.Glob(tmpFile + ".*")
+	assert.Len(t, matches, 1, "The temporary file should be removed")
+	if assert.Len(t, reported, 1) {
+		assert.ErrorContains(t, reported[0], "disk full")
+	}
+}
+
+func TestCompressRecoversOnStart(t *testing.T) {
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "testlog.txt")
+	rotated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Format(backupTimeFormat)
+
+	// A crash during a compression leaves a temporary file next to the original.
+	interrupted := tmpFile + "." + rotated
+	assert.NoError(t, os.WriteFile(interrupted, []byte("interrupted\n"), 0644))
+	assert.NoError(t, os.WriteFile(interrupted+".gz"+tempSuffix, []byte("partial"), 0644))
+
+	// A crash after the rename leaves the original next to the compressed backup.
+	completed := tmpFile + "." + rotated + "-1"
+	var compressed bytes.Buffer
+	assert.NoError(t, Gzip.Compress(&compressed, bytes.NewReader([]byte("completed\n"))))
+	assert.NoError(t, os.WriteFile(completed, []byte("completed\n"), 0644))
+	assert.NoError(t, os.WriteFile(completed+".gz", compressed.Bytes(), 0644))
+
+	writer, err := NewWriter(tmpFile, Compress(Gzip))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close(context.Background()))
+
+	assert.NoFileExists(t, interrupted+".gz"+tempSuffix)
+	assert.NoFileExists(t, interrupted)
+	assert.Equal(t, "interrupted\n", decompress(t, Gzip, interrupted+".gz"))
+	assert.NoFileExists(t, completed)
+	assert.Equal(t, "completed\n", decompress(t, Gzip, completed+".gz"))
+}
+
+func TestTemporaryFilesRemovedOnStart(t *testing.T) {
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "testlog.txt")
+	rotated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Format(backupTimeFormat)
+
+	interrupted := tmpFile + "." + rotated
+	assert.NoError(t, os.WriteFile(interrupted, []byte("interrupted\n"), 0644))
+	assert.NoError(t, os.WriteFile(interrupted+".gz"+tempSuffix, []byte("partial"), 0644))
+	assert.NoError(t, os.WriteFile(interrupted+"-1.zst"+tempSuffix, []byte("partial"), 0644))
+
+	// The compression is turned off or changed when the writer restarts.
+	for _, opts := range [][]Option{nil, {Compress(Zstd)}} {
+		writer, err := NewWriter(tmpFile, opts...)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close(context.Background()))
+
+		assert.NoFileExists(t, interrupted+".gz"+tempSuffix)
+		assert.NoFileExists(t, interrupted+"-1.zst"+tempSuffix)
+	}
+	assert.Equal(t, "interrupted\n", decompress(t, Zstd, interrupted+".zst"))
+}
+
+func TestMaxBackupsCountsCompressedBackups(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writer, err := NewWriter(tmpFile, MaxSize(1), MaxBackups(2), Compress(Gzip))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Minute)
+		assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "entry"}))
+		writer.compressions.Wait()
+	}
+	assert.NoError(t, writer.Close(context.Background()))
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+}
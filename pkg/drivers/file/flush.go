@@ -0,0 +1,127 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/app"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// FlushInterval flushes the buffered entries every interval, so the entries of an idle service
+// reach the file even when no entry at the FlushLevel is recorded. The default is 0, which disables it.
+func FlushInterval(interval time.Duration) Option {
+	return func(w *Writer) { w.flushInterval = interval }
+}
+
+// FlushLevel flushes the buffer as soon as an entry at level or above is recorded,
+// the default is log.ErrorLevel.
+func FlushLevel(level log.Level) Option {
+	return func(w *Writer) { w.flushLevel = level }
+}
+
+// SyncOnFlush calls fsync after every flush, including the ones triggered by FlushLevel and FlushInterval,
+// so the flushed entries survive a crash of the machine. By default the writer never calls fsync.
+func SyncOnFlush() Option {
+	return func(w *Writer) { w.syncOnFlush = true }
+}
+
+// SyncEvery flushes the buffer and calls fsync once at least bytes have been written since the last fsync.
+// It bounds the amount of entries that can be lost, at a lower cost than SyncOnFlush for busy services.
+func SyncEvery(bytes int64) Option {
+	return func(w *Writer) { w.syncEvery = bytes }
+}
+
+func (w *Writer) validateFlush() error {
+	switch {
+	case w.flushInterval < 0:
+		return fmt.Errorf("invalid flush interval: %v", w.flushInterval)
+	case !log.IsKnown(w.flushLevel):
+		return fmt.Errorf("invalid flush level: %v", w.flushLevel)
+	case w.syncEvery < 0:
+		return fmt.Errorf("invalid sync size: %v", w.syncEvery)
+	}
+	return nil
+}
+
+// Sync flushes the buffered entries and calls fsync, whatever the sync policy is.
+// Callers can use it to make sure the entries are durable before a risky operation.
+// The fallback driver is synced as well, see app.SyncDriver.
+func (w *Writer) Sync(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	err := w.sync()
+	if err != nil {
+		err = w.fail(fmt.Errorf("error syncing file: %w", err))
+	}
+	if w.fallback != nil {
+		err = errors.Join(err, app.SyncDriver(ctx, w.fallback))
+	}
+	return err
+}
+
+// flush writes the buffer to the file and calls fsync when SyncOnFlush is set.
+// It must be called with mu held.
+func (w *Writer) flush() error {
+	if err := w.flushBuffer(); err != nil {
+		return err
+	}
+	if w.syncOnFlush {
+		return w.syncFile()
+	}
+	return nil
+}
+
+// sync writes the buffer to the file and calls fsync. It must be called with mu held.
+func (w *Writer) sync() error {
+	if err := w.flushBuffer(); err != nil {
+		return err
+	}
+	return w.syncFile()
+}
+
+// flushBuffer writes the buffer to the file and clears the pending entries. It must be called with mu held.
+func (w *Writer) flushBuffer() error {
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
+
+func (w *Writer) syncFile() error {
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.unsynced = 0
+	return nil
+}
+
+// runFlusher flushes the buffer every flushInterval until Close is called.
+func (w *Writer) runFlusher() {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed && w.buffer.Buffered() > 0 {
+				if err := w.flush(); err != nil {
+					if err := w.fail(fmt.Errorf("error flushing file: %w", err)); err != nil {
+						w.reportError(err)
+					}
+				}
+			}
+			w.mu.Unlock()
+		case <-w.stopFlusher:
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestFlushLevel(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile, FlushLevel(log.WarnLevel))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.NoticeLevel, Message: "Buffered message"}))
+	assert.NotContains(t, readFile(t, tmpFile), "Buffered message")
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.WarnLevel, Message: "Warning message"}))
+	assert.Contains(t, readFile(t, tmpFile), "Buffered message")
+	assert.Contains(t, readFile(t, tmpFile), "Warning message")
+}
+
+func TestFlushInterval(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile, FlushInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Idle message"}))
+
+	assert.Eventually(t, func() bool {
+		content, err := os.ReadFile(tmpFile)
+		return err == nil && len(content) > 0
+	}, time.Second, 5*time.Millisecond, "The entry should be flushed without another write")
+	assert.Contains(t, readFile(t, tmpFile), "Idle message")
+
+	assert.NoError(t, writer.Close(context.Background()))
+}
+
+func TestSyncEvery(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile, SyncEvery(100))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	entry := log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Test log message"}
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.Empty(t, readFile(t, tmpFile), "The entry should stay buffered below the sync size")
+	assert.NotZero(t, writer.unsynced)
+
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NotEmpty(t, readFile(t, tmpFile), "The entries should be written once the sync size is reached")
+	assert.Zero(t, writer.unsynced)
+}
+
+func TestSync(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Test log message"}))
+	assert.NoError(t, writer.Sync(context.Background()))
+	assert.Contains(t, readFile(t, tmpFile), "Test log message")
+	assert.Zero(t, writer.unsynced)
+
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.NoError(t, writer.Sync(context.Background()), "Syncing a closed writer should have no effect")
+}
+
+func TestNewWriterInvalidFlushOptions(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	for _, opt := range []Option{FlushInterval(-time.Second), FlushLevel("LOUD"), SyncEvery(-1)} {
+		writer, err := NewWriter(tmpFile, opt)
+		assert.Error(t, err)
+		assert.Nil(t, writer)
+	}
+}
@@ -0,0 +1,137 @@
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ReopenOnSignal flushes and reopens the file when one of the signals is received,
+// for tools like logrotate that move the file and then signal the process.
+// SIGHUP is used when no signals are given. The handler is stopped by Close.
+func ReopenOnSignal(signals ...os.Signal) Option {
+	return func(w *Writer) {
+		if len(signals) == 0 {
+			signals = []os.Signal{syscall.SIGHUP}
+		}
+		w.reopenSignals = signals
+	}
+}
+
+// WatchFile checks, at most once every interval, whether the file was moved, deleted or truncated
+// by another process, e.g. by logrotate with copytruncate, and reopens it when it was.
+// The check runs while recording an entry and costs two stat calls. The default is 0, which disables it.
+func WatchFile(interval time.Duration) Option {
+	return func(w *Writer) { w.watchInterval = interval }
+}
+
+func (w *Writer) validateReopen() error {
+	if w.watchInterval < 0 {
+		return fmt.Errorf("invalid watch interval: %v", w.watchInterval)
+	}
+	return nil
+}
+
+// Reopen flushes the buffered entries to the current file, then opens fileName again.
+// The entries recorded afterwards go to the new file, and the size used by the rotation
+// is taken from it. If fileName cannot be opened, the writer keeps using the current file.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("writer for %v is closed", w.fileName)
+	}
+	return w.reopen()
+}
+
+// reopen must be called with mu held.
+func (w *Writer) reopen() error {
+	flushErr := w.flush()
+	if flushErr != nil {
+		// The buffered entries go to the fallback driver, the new file may still work.
+		flushErr = w.fail(fmt.Errorf("error flushing file: %w", flushErr))
+	}
+
+	file, err := openFile(w.fileName)
+	if err != nil {
+		return errors.Join(flushErr, fmt.Errorf("unable to reopen %v: %w", w.fileName, err))
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Join(flushErr, fmt.Errorf("unable to reopen %v: %w", w.fileName, err))
+	}
+
+	w.file.Close()
+	w.file = file
+	w.buffer = bufio.NewWriter(output{w})
+	w.currentSize = stat.Size()
+	w.unsynced = 0
+	return flushErr
+}
+
+// changedExternally reports whether fileName no longer refers to the open file,
+// or whether the file is shorter than what was written to it.
+// It must be called with mu held.
+func (w *Writer) changedExternally() (bool, error) {
+	current, err := w.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	stat, err := os.Stat(w.fileName)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	written := w.currentSize - int64(w.buffer.Buffered())
+	return !os.SameFile(current, stat) || stat.Size() < written, nil
+}
+
+// watch reopens the file when it was changed by another process, see WatchFile.
+// It must be called with mu held.
+func (w *Writer) watch(now time.Time) error {
+	if now.Before(w.nextWatch) {
+		return nil
+	}
+	w.nextWatch = now.Add(w.watchInterval)
+
+	changed, err := w.changedExternally()
+	if err != nil {
+		return fmt.Errorf("unable to check %v: %w", w.fileName, err)
+	}
+	if !changed {
+		return nil
+	}
+	return w.reopen()
+}
+
+// handleSignals reopens the file when one of the reopenSignals is received, until Close is called.
+func (w *Writer) handleSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, w.reopenSignals...)
+	w.stopSignals = make(chan struct{})
+
+	go func() {
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ch:
+				if err := w.Reopen(); err != nil {
+					w.reportError(err)
+				}
+			case <-w.stopSignals:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,97 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReopen(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	movedFile := tmpFile + ".1"
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Before the move"}))
+	assert.NoError(t, os.Rename(tmpFile, movedFile))
+	assert.NoError(t, writer.Reopen())
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "After the move"}))
+	assert.NoError(t, writer.Flush(context.Background()))
+
+	assert.Contains(t, readFile(t, movedFile), "Before the move", "Buffered entries should go to the moved file")
+	assert.NotContains(t, readFile(t, movedFile), "After the move")
+	assert.Contains(t, readFile(t, tmpFile), "After the move")
+	assert.Equal(t, int64(len(readFile(t, tmpFile))), writer.currentSize)
+}
+
+func TestReopenAfterClose(t *testing.T) {
+	writer, err := NewWriter(filepath.Join(t.TempDir(), "testlog.txt"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.Error(t, writer.Reopen())
+}
+
+func TestWatchFile(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	writer, err := NewWriter(tmpFile, WatchFile(time.Second))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	writer.now = func() time.Time { return now }
+
+	entry := log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Test log message"}
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NoError(t, writer.Flush(context.Background()))
+
+	// A truncation is only noticed once the interval has elapsed.
+	assert.NoError(t, os.Truncate(tmpFile, 0))
+	assert.NoError(t, writer.RecordLog(entry))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NoError(t, writer.Flush(context.Background()))
+	assert.Equal(t, int64(len(readFile(t, tmpFile))), writer.currentSize, "The size should be taken from the truncated file")
+
+	// A deleted file is created again.
+	assert.NoError(t, os.Remove(tmpFile))
+	now = now.Add(time.Second)
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NoError(t, writer.Flush(context.Background()))
+
+	assert.Contains(t, readFile(t, tmpFile), "Test log message")
+	assert.Equal(t, int64(len(readFile(t, tmpFile))), writer.currentSize)
+}
+
+func TestWatchFileFailure(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+	fallback := &fallbackDriver{}
+
+	writer, err := NewWriter(tmpFile, WatchFile(time.Second), Fallback(fallback), ErrorHandler(func(err error) {}))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	writer.now = func() time.Time { return now }
+
+	// The directory replaces the file, so reopening it fails.
+	assert.NoError(t, os.Remove(tmpFile))
+	assert.NoError(t, os.Mkdir(tmpFile, 0755))
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Test log message"}))
+	assert.True(t, writer.Health().Degraded)
+	assert.Len(t, fallback.entries, 1, "The entry should go to the fallback")
+}
+
+func TestNewWriterInvalidReopenOptions(t *testing.T) {
+	writer, err := NewWriter(filepath.Join(t.TempDir(), "testlog.txt"), WatchFile(-time.Second))
+	assert.Error(t, err)
+	assert.Nil(t, writer)
+}
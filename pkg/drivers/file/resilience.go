@@ -0,0 +1,174 @@
+package file
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/app"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// DefaultRetryBackoff is the first delay between two attempts to write to the file when Retry is not used.
+const DefaultRetryBackoff = time.Second
+
+// maxRecoveryDelay bounds the delay between two attempts to recover a degraded Writer.
+const maxRecoveryDelay = time.Minute
+
+// Retry retries a failed write up to attempts times before giving up on the entry,
+// reopening the file and waiting backoff before each attempt. The backoff doubles after every attempt.
+// The retries block the logging goroutine, so the attempts should be kept low.
+// Once the writer is degraded, backoff is also the first delay before trying the file again, see Health.
+func Retry(attempts int, backoff time.Duration) Option {
+	return func(w *Writer) {
+		w.retryAttempts = attempts
+		w.retryBackoff = backoff
+	}
+}
+
+// Fallback sets the driver receiving the entries while the file cannot be written,
+// e.g. cli.NewWriter() or a file.Writer for another disk. The driver follows the encoding
+// of the Writer, and it is flushed and closed together with the Writer.
+func Fallback(driver app.Driver) Option {
+	return func(w *Writer) { w.fallback = driver }
+}
+
+// Health describes whether a Writer can write to its file.
+type Health struct {
+	// Degraded is true while the entries cannot be written to the file.
+	// A degraded Writer sends the entries to the fallback driver and tries the file again
+	// after a delay that doubles up to a minute, until a write succeeds.
+	Degraded bool
+	// Since is the time of the first failed write, when Degraded.
+	Since time.Time
+	// Err is the last write error, when Degraded.
+	Err error
+	// FallbackEntries is the number of entries sent to the fallback driver since the Writer was created.
+	FallbackEntries int64
+	// LostEntries is the number of entries that reached neither the file nor the fallback driver
+	// since the Writer was created.
+	LostEntries int64
+}
+
+// Health returns the current health of the Writer.
+func (w *Writer) Health() Health {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return Health{
+		Degraded:        w.degraded(),
+		Since:           w.degradedSince,
+		Err:             w.lastErr,
+		FallbackEntries: w.fallbackEntries,
+		LostEntries:     w.lostEntries,
+	}
+}
+
+func (w *Writer) validateResilience() error {
+	switch {
+	case w.retryAttempts < 0:
+		return fmt.Errorf("invalid retry attempts: %v", w.retryAttempts)
+	case w.retryBackoff <= 0:
+		return fmt.Errorf("invalid retry backoff: %v", w.retryBackoff)
+	}
+	return nil
+}
+
+// output is the destination of the buffer, it retries the writes that fail, see Retry.
+// Retrying below the buffer keeps the buffered entries when a retry succeeds.
+type output struct {
+	w *Writer
+}
+
+func (o output) Write(p []byte) (int, error) {
+	w := o.w
+	backoff := w.retryBackoff
+
+	written := 0
+	for attempt := 0; ; attempt++ {
+		n, err := w.file.Write(p[written:])
+		written += n
+		if err == nil {
+			w.wrote = true
+			return written, nil
+		}
+		if attempt >= w.retryAttempts {
+			return written, err
+		}
+
+		w.sleep(backoff)
+		backoff *= 2
+		if file, err := openFile(w.fileName); err == nil {
+			w.file.Close()
+			w.file = file
+		}
+	}
+}
+
+// openFile opens fileName for appending, creating it if needed.
+func openFile(fileName string) (*os.File, error) {
+	return os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// degraded must be called with mu held.
+func (w *Writer) degraded() bool {
+	return !w.degradedSince.IsZero()
+}
+
+// fail marks the Writer as degraded after a failed write. The entries still in the buffer and the given
+// entries, which did not reach the buffer, are sent to the fallback driver.
+// It must be called with mu held.
+func (w *Writer) fail(err error, entries ...log.Entry) error {
+	now := w.now()
+	if !w.degraded() {
+		w.degradedSince = now
+		w.recoveryDelay = w.retryBackoff
+		if w.fallback != nil {
+			w.reportError(fmt.Errorf("unable to write to %v, using the fallback driver: %w", w.fileName, err))
+		}
+	} else {
+		w.recoveryDelay = min(2*w.recoveryDelay, maxRecoveryDelay)
+	}
+	w.lastErr = err
+	w.nextRecovery = now.Add(w.recoveryDelay)
+
+	// A buffer that failed rejects every later write, so it is replaced and its entries are replayed.
+	// The entries written partially before the failure may end up both in the file and in the fallback.
+	entries = append(w.pending, entries...)
+	w.pending = nil
+	w.buffer = bufio.NewWriter(output{w})
+	return w.fallBack(err, entries...)
+}
+
+// fallBack sends the entries to the fallback driver. It returns nil when the fallback driver recorded them,
+// and err otherwise. The entries that reach neither the file nor the fallback driver are counted as lost.
+// It must be called with mu held.
+func (w *Writer) fallBack(err error, entries ...log.Entry) error {
+	if w.fallback == nil {
+		w.lostEntries += int64(len(entries))
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if fallbackErr := w.fallback.RecordLog(entry); fallbackErr != nil {
+			w.lostEntries++
+			errs = append(errs, fallbackErr)
+			continue
+		}
+		w.fallbackEntries++
+	}
+	if len(errs) > 0 {
+		return errors.Join(err, fmt.Errorf("fallback driver: %w", errors.Join(errs...)))
+	}
+	return nil
+}
+
+// recovered marks the Writer as healthy again. It must be called with mu held.
+func (w *Writer) recovered() {
+	w.degradedSince = time.Time{}
+	w.lastErr = nil
+	w.recoveryDelay = 0
+}
@@ -0,0 +1,240 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type fallbackDriver struct {
+	entries  []log.Entry
+	encoding string
+	closed   bool
+}
+
+func (d *fallbackDriver) RecordLog(logInfo log.Entry) error {
+	d.entries = append(d.entries, logInfo)
+	return nil
+}
+
+func (d *fallbackDriver) SetEncoding(encoding string) error {
+	d.encoding = encoding
+	return nil
+}
+
+func (d *fallbackDriver) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (d *fallbackDriver) Close(ctx context.Context) error {
+	d.closed = true
+	return nil
+}
+
+// breakFile replaces the file of the writer with a read-only one, so every write to it fails.
+func breakFile(t *testing.T, w *Writer) {
+	t.Helper()
+	file, err := os.Open(w.fileName)
+	assert.NoError(t, err)
+	w.file.Close()
+	w.file = file
+}
+
+func TestRetry(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile, Retry(3, 10*time.Millisecond))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	var delays []time.Duration
+	writer.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Buffered message"}))
+	breakFile(t, writer)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.ErrorLevel, Message: "Error message"}))
+
+	assert.Equal(t, []time.Duration{10 * time.Millisecond}, delays, "The file should be reopened after the first failure")
+	assert.False(t, writer.Health().Degraded)
+	assert.Contains(t, readFile(t, tmpFile), "Buffered message", "A successful retry should keep the buffered entries")
+	assert.Contains(t, readFile(t, tmpFile), "Error message")
+}
+
+func TestFallbackAndRecovery(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+	fallback := &fallbackDriver{}
+
+	writer, err := NewWriter(tmpFile, Fallback(fallback), Retry(0, time.Second), ErrorHandler(func(err error) {}))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+
+	assert.NoError(t, writer.SetEncoding(JSONEncoding))
+	assert.Equal(t, JSONEncoding, fallback.encoding, "The fallback should follow the encoding")
+
+	breakFile(t, writer)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.ErrorLevel, Message: "First failure"}))
+
+	health := writer.Health()
+	assert.True(t, health.Degraded)
+	assert.Equal(t, now, health.Since)
+	assert.Error(t, health.Err)
+
+	// The file is not tried again before the backoff elapsed.
+	now = now.Add(500 * time.Millisecond)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "While degraded"}))
+	assert.Equal(t, int64(2), writer.Health().FallbackEntries)
+
+	now = now.Add(time.Second)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Recovered"}))
+
+	health = writer.Health()
+	assert.False(t, health.Degraded)
+	assert.Nil(t, health.Err)
+	assert.Len(t, fallback.entries, 2)
+	assert.Contains(t, readFile(t, tmpFile), "Recovered", "The probing entry should be flushed to the file")
+
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.True(t, fallback.closed)
+}
+
+func TestRecoveryBackoff(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	writer, err := NewWriter(tmpFile, Fallback(&fallbackDriver{}), Retry(0, 40*time.Second), ErrorHandler(func(err error) {}))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	writer.now = func() time.Time { return now }
+
+	// The directory replaces the file, so reopening it fails as well.
+	assert.NoError(t, os.Remove(tmpFile))
+	assert.NoError(t, os.Mkdir(tmpFile, 0755))
+	breakFile(t, writer)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.ErrorLevel, Message: "First failure"}))
+	assert.Equal(t, now.Add(40*time.Second), writer.nextRecovery)
+
+	now = writer.nextRecovery
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.ErrorLevel, Message: "Second failure"}))
+	assert.Equal(t, now.Add(maxRecoveryDelay), writer.nextRecovery, "The delay should double up to the maximum")
+	assert.Equal(t, int64(2), writer.Health().FallbackEntries)
+}
+
+func TestWriteFailureWithoutFallback(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	breakFile(t, writer)
+	assert.Error(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.ErrorLevel, Message: "Lost message"}))
+	assert.Error(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.ErrorLevel, Message: "Lost message"}),
+		"The entries should be rejected until the file is tried again")
+	assert.True(t, writer.Health().Degraded)
+}
+
+func TestFailureReplaysBufferedEntries(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	fallback := &fallbackDriver{}
+
+	writer, err := NewWriter(tmpFile, Fallback(fallback), ErrorHandler(func(err error) {}))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Buffered message"}))
+	breakFile(t, writer)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.ErrorLevel, Message: "Error message"}))
+
+	if assert.Len(t, fallback.entries, 2, "The buffered entry should be replayed to the fallback") {
+		assert.Equal(t, "Buffered message", fallback.entries[0].Message)
+		assert.Equal(t, "Error message", fallback.entries[1].Message)
+	}
+	assert.Zero(t, writer.Health().LostEntries)
+}
+
+func TestLostEntries(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Buffered message"}))
+	breakFile(t, writer)
+	assert.Error(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.ErrorLevel, Message: "Error message"}))
+	assert.Equal(t, int64(2), writer.Health().LostEntries, "Without a fallback the buffered entry is lost as well")
+}
+
+func TestRotateFileFlushFailure(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+	fallback := &fallbackDriver{}
+
+	writer, err := NewWriter(tmpFile, RotateEvery(time.Hour), Fallback(fallback), ErrorHandler(func(err error) {}))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	writer.now = func() time.Time { return now }
+	writer.nextRotation = nextBoundary(now, time.Hour)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Buffered message"}))
+	breakFile(t, writer)
+
+	now = now.Add(time.Hour)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Next interval"}))
+	assert.True(t, writer.Health().Degraded)
+	if assert.Len(t, fallback.entries, 2, "The buffered and the current entry should go to the fallback") {
+		assert.Equal(t, "Buffered message", fallback.entries[0].Message)
+		assert.Equal(t, "Next interval", fallback.entries[1].Message)
+	}
+}
+
+func TestRotateFileRenameFailure(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local)
+
+	writer, err := NewWriter(tmpFile, MaxSize(10), Retry(0, time.Second))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+	writer.now = func() time.Time { return now }
+
+	// The file disappears, so the rename of the rotation fails.
+	assert.NoError(t, os.Remove(tmpFile))
+	err = writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "Before the failure"})
+	assert.ErrorContains(t, err, "failed to rename log file")
+
+	assert.Zero(t, writer.currentSize, "The size should be taken from the reopened file")
+	assert.Equal(t, now.Add(time.Second), writer.nextRotationAttempt)
+
+	// The rotation is not attempted again before the backoff elapsed.
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "During the backoff"}))
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+
+	// The next entry is written to the reopened file, which is then rotated.
+	now = now.Add(time.Second)
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: now, Level: log.InfoLevel, Message: "After the failure"}))
+	backups, err = writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		assert.Contains(t, readFile(t, backups[0].path), "After the failure", "The writer should keep a usable file")
+	}
+	assert.Zero(t, writer.rotationDelay, "A successful rotation should reset the backoff")
+}
+
+func TestNewWriterInvalidResilienceOptions(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	for _, opt := range []Option{Retry(-1, time.Second), Retry(1, 0)} {
+		writer, err := NewWriter(tmpFile, opt)
+		assert.Error(t, err)
+		assert.Nil(t, writer)
+	}
+}
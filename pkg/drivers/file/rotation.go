@@ -0,0 +1,211 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxSize is the size after which the file is rotated when MaxSize is not set.
+const DefaultMaxSize = 10 * 1024 * 1024 // 10MB
+
+// backupTimeFormat names the backups after the rotation time, they sort in rotation order.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// legacyBackupDigits is the length of the Unix time in seconds that named the backups of the previous versions,
+// e.g. "app.log.1704855600". Shorter numbers are left alone, they are likely the backups of another tool.
+const legacyBackupDigits = 10
+
+// MaxSize rotates the file once it grows over size bytes, 0 disables the size based rotation.
+func MaxSize(size int64) Option {
+	return func(w *Writer) { w.maxSize = size }
+}
+
+// RotateEvery rotates the file at every interval, aligned to the wall clock: an hourly interval rotates
+// at the start of every hour and a daily interval at local midnight.
+// Intervals that do not divide a day are aligned to multiples of the interval since the zero time.
+func RotateEvery(interval time.Duration) Option {
+	return func(w *Writer) { w.interval = interval }
+}
+
+// MaxBackups deletes the oldest backups when there are more than count of them, 0 keeps all of them.
+func MaxBackups(count int) Option {
+	return func(w *Writer) { w.maxBackups = count }
+}
+
+// MaxAge deletes the backups rotated more than age ago, 0 keeps all of them.
+func MaxAge(age time.Duration) Option {
+	return func(w *Writer) { w.maxAge = age }
+}
+
+// validateRotation checks the rotation options.
+func (w *Writer) validateRotation() error {
+	switch {
+	case w.maxSize < 0:
+		return fmt.Errorf("invalid max size: %v", w.maxSize)
+	case w.interval < 0:
+		return fmt.Errorf("invalid rotation interval: %v", w.interval)
+	case w.maxBackups < 0:
+		return fmt.Errorf("invalid max backups: %v", w.maxBackups)
+	case w.maxAge < 0:
+		return fmt.Errorf("invalid max age: %v", w.maxAge)
+	}
+	return nil
+}
+
+// nextBoundary returns the first rotation time after t.
+func nextBoundary(t time.Time, interval time.Duration) time.Time {
+	const day = 24 * time.Hour
+	if day%interval != 0 {
+		return t.Truncate(interval).Add(interval)
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	nextMidnight := midnight.AddDate(0, 0, 1)
+	next := midnight.Add(t.Sub(midnight).Truncate(interval) + interval)
+	// Days are shorter or longer than 24 hours when the daylight saving time changes.
+	if next.After(nextMidnight) {
+		return nextMidnight
+	}
+	return next
+}
+
+// backupName returns a name for a backup rotated at t that is not used yet.
+func (w *Writer) backupName(t time.Time) string {
+	name := w.fileName + "." + t.UTC().Format(backupTimeFormat)
+	candidate := name
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+}
+
+// backup is a rotated file.
+type backup struct {
+	path       string
+	rotated    time.Time
+	compressed bool
+}
+
+// backups returns the rotated files of the writer, from the oldest to the newest.
+func (w *Writer) backups() ([]backup, error) {
+	dir := filepath.Dir(w.fileName)
+	prefix := filepath.Base(w.fileName) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suffix, compressed := trimCompressionExtension(strings.TrimPrefix(name, prefix))
+		rotated, ok := parseBackupTime(suffix)
+		if !ok || !compressed && hasCompressedCopy(names, name) {
+			// A backup that is being compressed is listed once, as its compressed copy.
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), rotated: rotated, compressed: compressed})
+	}
+
+	slices.SortFunc(backups, func(a, b backup) int {
+		if c := a.rotated.Compare(b.rotated); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	return backups, nil
+}
+
+// trimCompressionExtension removes the extension of a compressed backup from suffix,
+// and reports whether there was one.
+func trimCompressionExtension(suffix string) (string, bool) {
+	for _, ext := range compressionExtensions {
+		if strings.HasSuffix(suffix, ext) {
+			return strings.TrimSuffix(suffix, ext), true
+		}
+	}
+	return suffix, false
+}
+
+// hasCompressedCopy reports whether names contains a compressed copy of the backup name.
+func hasCompressedCopy(names map[string]bool, name string) bool {
+	for _, ext := range compressionExtensions {
+		if names[name+ext] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBackupTime parses the rotation time of a backup suffix, e.g. "20240102T030405.000000000-1",
+// without the compression extension. The Unix time of the backups of the previous versions is accepted as well.
+// Other suffixes, e.g. the ones of unrelated or temporary files, are rejected.
+func parseBackupTime(suffix string) (time.Time, bool) {
+	if len(suffix) == legacyBackupDigits && strings.Trim(suffix, "0123456789") == "" {
+		seconds, err := strconv.ParseInt(suffix, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(seconds, 0), true
+	}
+
+	if len(suffix) < len(backupTimeFormat) {
+		return time.Time{}, false
+	}
+	rotated, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	rest := suffix[len(backupTimeFormat):]
+	if rest != "" && (rest[0] != '-' || strings.Trim(rest[1:], "0123456789") != "") {
+		return time.Time{}, false
+	}
+	return rotated, true
+}
+
+// removeOldBackups deletes the backups beyond MaxBackups and the ones older than MaxAge.
+func (w *Writer) removeOldBackups(now time.Time) error {
+	if w.maxBackups == 0 && w.maxAge == 0 {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return fmt.Errorf("failed to list log backups: %w", err)
+	}
+
+	var errs []error
+	for i, b := range backups {
+		tooMany := w.maxBackups > 0 && len(backups)-i > w.maxBackups
+		tooOld := w.maxAge > 0 && now.Sub(b.rotated) > w.maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove log backup: %w", err))
+		}
+		if b.compressed {
+			// The original may still be there if the compression just finished.
+			os.Remove(strings.TrimSuffix(b.path, filepath.Ext(b.path)))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	movedFile := tmpFile + ".1"
+
+	writer, err := NewWriter(tmpFile, ReopenOnSignal(syscall.SIGUSR2))
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Before the move"}))
+	assert.NoError(t, os.Rename(tmpFile, movedFile))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(tmpFile)
+		return err == nil
+	}, time.Second, 5*time.Millisecond, "The file should be reopened")
+	assert.Contains(t, readFile(t, movedFile), "Before the move", "Buffered entries should be flushed before reopening")
+}
@@ -2,12 +2,16 @@ package file
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/ralugr/datacollector/pkg/app"
 	"github.com/ralugr/datacollector/pkg/log"
 )
 
@@ -16,8 +20,6 @@ const (
 	JSONEncoding  = "json"
 )
 
-const maxFileSize = 10 * 1024 * 1024 // 10MB
-
 // supports json and plain text
 type Writer struct {
 	encoding    string
@@ -25,11 +27,103 @@ type Writer struct {
 	fileName    string
 	currentSize int64
 	buffer      *bufio.Writer
+	duplicates  log.DuplicateKeys
+	closed      bool
 	mu          sync.Mutex
+
+	// The rotation options, see rotation.go.
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	maxAge     time.Duration
+	// nextRotation is the time of the next interval based rotation.
+	nextRotation time.Time
+	// rotationDelay and nextRotationAttempt postpone the rotation after a failed rename.
+	rotationDelay       time.Duration
+	nextRotationAttempt time.Time
+	// now is replaced in tests to control the rotation time.
+	now func() time.Time
+
+	// compressor compresses the backups in the background, see compression.go.
+	compressor   Compressor
+	compressions sync.WaitGroup
+	compressMu   sync.Mutex
+	onError      func(err error)
+
+	// The flush and sync options, see flush.go.
+	flushInterval time.Duration
+	flushLevel    log.Level
+	syncOnFlush   bool
+	syncEvery     int64
+	// unsynced is the number of bytes written since the last fsync.
+	unsynced    int64
+	stopFlusher chan struct{}
+
+	// The reopen options, see reopen.go.
+	reopenSignals []os.Signal
+	stopSignals   chan struct{}
+	watchInterval time.Duration
+	// nextWatch is the time of the next check for external changes.
+	nextWatch time.Time
+
+	// The resilience options and state, see resilience.go.
+	retryAttempts int
+	retryBackoff  time.Duration
+	fallback      app.Driver
+	// sleep is replaced in tests to avoid waiting for the retries.
+	sleep           func(time.Duration)
+	degradedSince   time.Time
+	lastErr         error
+	recoveryDelay   time.Duration
+	nextRecovery    time.Time
+	fallbackEntries int64
+	lostEntries     int64
+	// pending are the entries still in the buffer, they are replayed to the fallback driver
+	// when the buffer cannot be written. wrote is set by output when the buffer reached the file.
+	pending []log.Entry
+	wrote   bool
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// DuplicateKeys sets how attributes with the same key are written with the json encoding, the default is log.KeepLast.
+func DuplicateKeys(policy log.DuplicateKeys) Option {
+	return func(w *Writer) {
+		w.duplicates = policy
+	}
 }
 
-func NewWriter(fileName string) (*Writer, error) {
-	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// NewWriter opens or creates fileName and appends the entries to it.
+// The file is rotated when it grows over DefaultMaxSize, see the rotation options to change that.
+// The entries are buffered until an entry at log.ErrorLevel or above is recorded, see the flush options to change that.
+func NewWriter(fileName string, opts ...Option) (*Writer, error) {
+	w := &Writer{
+		encoding:     PlainEncoding,
+		fileName:     fileName,
+		maxSize:      DefaultMaxSize,
+		now:          time.Now,
+		flushLevel:   log.ErrorLevel,
+		retryBackoff: DefaultRetryBackoff,
+		sleep:        time.Sleep,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if err := w.validateRotation(); err != nil {
+		return nil, err
+	}
+	if err := w.validateFlush(); err != nil {
+		return nil, err
+	}
+	if err := w.validateReopen(); err != nil {
+		return nil, err
+	}
+	if err := w.validateResilience(); err != nil {
+		return nil, err
+	}
+
+	file, err := openFile(fileName)
 	if err != nil {
 		return nil, fmt.Errorf("Error opening file %v: %v\n", fileName, err)
 	}
@@ -39,102 +133,297 @@ func NewWriter(fileName string) (*Writer, error) {
 		return nil, fmt.Errorf("Error getting file info for %v: %w", fileName, err)
 	}
 
-	return &Writer{
-		encoding:    PlainEncoding,
-		file:        file,
-		fileName:    fileName,
-		currentSize: stat.Size(),
-		buffer:      bufio.NewWriter(file),
-	}, nil
+	w.file = file
+	w.currentSize = stat.Size()
+	w.buffer = bufio.NewWriter(output{w})
+	if w.interval > 0 {
+		// A file written during a previous interval is rotated before the first entry of this one.
+		start := w.now()
+		if stat.Size() > 0 {
+			start = stat.ModTime()
+		}
+		w.nextRotation = nextBoundary(start, w.interval)
+	}
+
+	if err := w.recoverCompression(); err != nil {
+		w.reportError(err)
+	}
+	if w.flushInterval > 0 {
+		w.stopFlusher = make(chan struct{})
+		go w.runFlusher()
+	}
+	if len(w.reopenSignals) > 0 {
+		w.handleSignals()
+	}
+	return w, nil
 }
 
-func (w *Writer) Close() {
-	w.buffer.Flush()
-	w.file.Close()
+// Flush writes the buffered entries to the file, followed by fsync when SyncOnFlush is set.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	err := w.flush()
+	if err != nil {
+		err = w.fail(fmt.Errorf("error flushing file: %w", err))
+	}
+	if w.fallback != nil {
+		err = errors.Join(err, w.fallback.Flush(ctx))
+	}
+	return err
 }
 
-func (w *Writer) SetEncoding(encoding string) {
+// Close flushes the buffered entries and closes the file and the fallback driver.
+// It then waits for the background compressions, unless ctx is done first.
+// Calling Close more than once has no effect.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.stopFlusher != nil {
+		close(w.stopFlusher)
+	}
+	if w.stopSignals != nil {
+		close(w.stopSignals)
+	}
+
+	err := w.flush()
+	if err != nil {
+		err = w.fail(fmt.Errorf("error flushing file: %w", err))
+	}
+	err = errors.Join(err, w.file.Close())
+	if w.fallback != nil {
+		err = errors.Join(err, w.fallback.Close(ctx))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.compressions.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Join(err, fmt.Errorf("log backups are still being compressed: %w", ctx.Err()))
+	}
+}
+
+func (w *Writer) SetEncoding(encoding string) error {
 	if encoding != PlainEncoding && encoding != JSONEncoding {
-		fmt.Fprintf(os.Stderr, "Unknown encoding %v\n", encoding)
-		return
+		return fmt.Errorf("unknown encoding %v", encoding)
 	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.encoding = encoding
+	if w.fallback != nil {
+		return w.fallback.SetEncoding(encoding)
+	}
+	return nil
 }
 
-func (w *Writer) RecordLog(logInfo log.Entry) {
-	var err error
-	var bytes int
+func (w *Writer) RecordLog(logInfo log.Entry) error {
+	var line string
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.closed {
+		return fmt.Errorf("writer for %v is closed", w.fileName)
+	}
+
 	switch w.encoding {
-	case PlainEncoding:
-		bytes, err = w.buffer.WriteString(w.logEntryToString(logInfo) + "\n")
 	case JSONEncoding:
-		bytes, err = w.buffer.WriteString(w.logEntryToJson(logInfo) + "\n")
+		jsonLine, err := w.logEntryToJson(logInfo)
+		if err != nil {
+			return err
+		}
+		line = jsonLine
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown encoding: %v. Defaulting to %v\n", w.encoding, PlainEncoding)
-		bytes, err = w.buffer.WriteString(w.logEntryToString(logInfo) + "\n")
+		line = w.logEntryToString(logInfo)
 	}
 
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to file: %v\n", err)
-		return
+	now := w.now()
+	probing := w.degraded()
+	if probing {
+		if now.Before(w.nextRecovery) {
+			return w.fallBack(fmt.Errorf("log file %v is unavailable: %w", w.fileName, w.lastErr), logInfo)
+		}
+		if err := w.reopen(); err != nil {
+			return w.fail(fmt.Errorf("error reopening log file: %w", err), logInfo)
+		}
+	}
+
+	if w.watchInterval > 0 {
+		if err := w.watch(now); err != nil {
+			return w.fail(fmt.Errorf("error reopening log file: %w", err), logInfo)
+		}
 	}
 
-	if logInfo.Level == log.ErrorLevel {
-		w.buffer.Flush()
+	// The interval rotation happens before writing, so the entry goes to the file of its interval.
+	// A failed rename leaves the current file open, so the entry is still written to it.
+	var rotationErr error
+	if w.interval > 0 && !now.Before(w.nextRotation) {
+		if err := w.rotateFile(now); err != nil {
+			rotationErr = fmt.Errorf("error rotating log file: %w", err)
+		}
+		if w.degraded() && now.Before(w.nextRecovery) {
+			// The rotation left no file to write to, so the entry follows the buffered ones to the fallback driver.
+			return errors.Join(rotationErr, w.fallBack(nil, logInfo))
+		}
+	}
+
+	if err := w.write(logInfo, line); err != nil {
+		return errors.Join(rotationErr, w.fail(err, logInfo))
+	}
+	if probing {
+		// The file is only known to work again once the entry reached it.
+		if err := w.flushBuffer(); err != nil {
+			return w.fail(fmt.Errorf("error flushing file: %w", err))
+		}
+		w.recovered()
+	}
+
+	if w.maxSize > 0 && w.currentSize > w.maxSize && !now.Before(w.nextRotationAttempt) {
+		if err := w.rotateFile(w.now()); err != nil {
+			return errors.Join(rotationErr, fmt.Errorf("error rotating log file: %w", err))
+		}
+	}
+
+	return rotationErr
+}
+
+// postponeRotation delays the next rotation after a failed rename, instead of renaming the file again
+// for every entry. The delay starts at the retry backoff and doubles up to maxRecoveryDelay.
+// It must be called with mu held.
+func (w *Writer) postponeRotation(now time.Time) {
+	w.rotationDelay = min(max(2*w.rotationDelay, w.retryBackoff), maxRecoveryDelay)
+	w.nextRotationAttempt = now.Add(w.rotationDelay)
+	if w.interval > 0 {
+		w.nextRotation = w.nextRotationAttempt
+	}
+}
+
+// write adds the line of the entry to the buffer and then flushes or syncs it, depending on the options.
+// When the entry stays in the buffer, it is added to the pending entries.
+// It must be called with mu held.
+func (w *Writer) write(entry log.Entry, line string) error {
+	w.wrote = false
+	bytes, err := w.buffer.WriteString(line + "\n")
+	if w.wrote {
+		// The buffer was written to make room for the line, the previous entries reached the file.
+		w.pending = w.pending[:0]
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
 	}
 
 	w.currentSize += int64(bytes) // includes buffer size as well
-	if w.currentSize > maxFileSize {
-		err := w.rotateFile()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error rotating log file: %v\n", err)
+	w.unsynced += int64(bytes)
+
+	if w.syncEvery > 0 && w.unsynced >= w.syncEvery {
+		if err := w.sync(); err != nil {
+			return fmt.Errorf("error syncing file: %w", err)
+		}
+	} else if log.IsValid(w.flushLevel, entry.Level) {
+		if err := w.flush(); err != nil {
+			return fmt.Errorf("error flushing file: %w", err)
 		}
 	}
 
+	if w.buffer.Buffered() > 0 {
+		w.pending = append(w.pending, entry)
+	}
+	return nil
 }
 
-func (w *Writer) logEntryToString(log log.Entry) string {
-	s := fmt.Sprintf("time:%v, level:%v, app_name:%v, message:%v, attributes:%v", log.Timestamp.UTC().Format(time.RFC3339), log.Level,
-		log.AppName, log.Message, log.Attributes)
+func (w *Writer) logEntryToString(entry log.Entry) string {
+	s := fmt.Sprintf("time:%v, level:%v, app_name:%v, message:%v, attributes:%v", entry.Timestamp.UTC().Format(time.RFC3339), entry.Level,
+		entry.AppName, entry.Message, log.Flatten(entry.Attributes))
+
+	if entry.Logger != "" {
+		s += fmt.Sprintf(" logger:%v", entry.Logger)
+	}
 
-	if log.TransactionID != "" {
-		s += fmt.Sprintf(" transaction_id:%v", log.TransactionID)
+	if entry.TransactionID != "" {
+		s += fmt.Sprintf(" transaction_id:%v", entry.TransactionID)
+	}
+
+	if entry.SpanID != "" {
+		s += fmt.Sprintf(" span_id:%v parent_span_id:%v", entry.SpanID, entry.ParentSpanID)
+	}
+
+	if entry.Source != nil {
+		s += fmt.Sprintf(" source:%v", entry.Source)
 	}
 
 	return s
 }
 
-func (w *Writer) logEntryToJson(log log.Entry) string {
-	jsonData, err := json.MarshalIndent(log, "", "  ")
+func (w *Writer) logEntryToJson(entry log.Entry) (string, error) {
+	jsonData, err := log.MarshalEntry(entry, w.duplicates)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to marshal %v: %v\n", log, err)
-		return ""
+		return "", fmt.Errorf("unable to marshal %v: %w", entry, err)
 	}
 
-	return string(jsonData)
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, jsonData, "", "  "); err != nil {
+		return "", fmt.Errorf("unable to marshal %v: %w", entry, err)
+	}
+	return indented.String(), nil
 }
 
-// rotateFile closes the current file, rename and opens a new file
-func (w *Writer) rotateFile() error {
-	w.buffer.Flush()
-	w.file.Close()
-
-	newFilename := fmt.Sprintf("%s.%d", w.fileName, time.Now().Unix())
-	err := os.Rename(w.fileName, newFilename)
+// rotateFile closes the current file, renames it to a backup named after now and opens a new file.
+// The backups beyond MaxBackups or older than MaxAge are then deleted.
+// The file is opened again even when the rename fails, so the writer can keep writing to it.
+// When the buffer cannot be flushed or the file cannot be opened again, the writer is degraded, see fail.
+func (w *Writer) rotateFile(now time.Time) error {
+	var err error
+	if w.syncEvery > 0 {
+		// The bytes still waiting for fsync belong to the backup.
+		err = w.sync()
+	} else {
+		err = w.flush()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to rename log file: %w", err)
+		return w.fail(fmt.Errorf("failed to flush log file: %w", err))
 	}
+	w.file.Close()
 
-	file, err := os.OpenFile(w.fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	backupName := w.backupName(now)
+	renameErr := os.Rename(w.fileName, backupName)
+
+	file, err := openFile(w.fileName)
 	if err != nil {
-		return err
+		return w.fail(errors.Join(renameErr, fmt.Errorf("failed to open log file: %w", err)))
 	}
 	w.file = file
-	w.buffer = bufio.NewWriter(file)
+	if renameErr != nil {
+		if stat, err := file.Stat(); err == nil {
+			w.currentSize = stat.Size()
+		}
+		w.postponeRotation(now)
+		return fmt.Errorf("failed to rename log file: %w", renameErr)
+	}
+
 	w.currentSize = 0
-	return nil
+	w.unsynced = 0
+	w.rotationDelay = 0
+	w.nextRotationAttempt = time.Time{}
+	if w.interval > 0 {
+		w.nextRotation = nextBoundary(now, w.interval)
+	}
+
+	if w.compressor != nil {
+		w.compressBackup(backupName)
+	}
+	return w.removeOldBackups(now)
 }
@@ -1,6 +1,8 @@
 package file
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,10 +30,10 @@ func TestSetEncoding(t *testing.T) {
 	writer, err := NewWriter(tmpFile)
 	assert.NoError(t, err)
 
-	writer.SetEncoding(JSONEncoding)
+	assert.NoError(t, writer.SetEncoding(JSONEncoding))
 	assert.Equal(t, JSONEncoding, writer.encoding)
 
-	writer.SetEncoding("unknown")
+	assert.Error(t, writer.SetEncoding("unknown"))
 	assert.Equal(t, JSONEncoding, writer.encoding) // Encoding shouldn't change
 }
 
@@ -50,8 +52,8 @@ func TestRecordLogPlainEncoding(t *testing.T) {
 		Attributes: []log.Attrb{log.Attr("key", "value")},
 	}
 
-	writer.RecordLog(entry)
-	writer.Close()
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
 
 	content, err := os.ReadFile(tmpFile)
 	assert.NoError(t, err)
@@ -61,6 +63,83 @@ func TestRecordLogPlainEncoding(t *testing.T) {
 	assert.Contains(t, string(content), expected)
 }
 
+func TestRecordLogPlainEncodingGroups(t *testing.T) {
+	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
+	defer os.Remove(tmpFile)
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+
+	entry := log.Entry{
+		Timestamp:  time.Now(),
+		Level:      log.InfoLevel,
+		AppName:    "TestApp",
+		Message:    "Request served",
+		Attributes: []log.Attrb{log.Group("http", log.String("method", "GET"), log.Group("response", log.Int("status", 200)))},
+	}
+
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
+
+	content, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "attributes:[{http.method GET} {http.response.status 200}]")
+}
+
+func TestRecordLogErrorAttribute(t *testing.T) {
+	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
+	defer os.Remove(tmpFile)
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+
+	entry := log.Entry{
+		Timestamp:  time.Now(),
+		Level:      log.ErrorLevel,
+		AppName:    "TestApp",
+		Message:    "Payment failed",
+		Attributes: []log.Attrb{log.Err(fmt.Errorf("charging card: %w", errors.New("declined")))},
+	}
+
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NoError(t, writer.SetEncoding(JSONEncoding))
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
+
+	content, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "attributes:[{error charging card: declined [*fmt.wrapError > *errors.errorString]}]")
+	assert.Contains(t, string(content), `"type": "*fmt.wrapError"`)
+	assert.Contains(t, string(content), `"message": "declined"`)
+}
+
+func TestRecordLogSource(t *testing.T) {
+	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
+	defer os.Remove(tmpFile)
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+
+	entry := log.Entry{
+		Timestamp: time.Now(),
+		Level:     log.InfoLevel,
+		AppName:   "TestApp",
+		Message:   "Test log message",
+		Source:    &log.Frame{Function: "main.main", File: "/src/main.go", Line: 12},
+	}
+
+	assert.NoError(t, writer.RecordLog(entry))
+	assert.NoError(t, writer.SetEncoding(JSONEncoding))
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
+
+	content, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), " source:main.main (/src/main.go:12)\n")
+	assert.Contains(t, string(content), `"source": {`)
+	assert.Contains(t, string(content), `"file": "/src/main.go"`)
+}
+
 func TestRecordLogJSONEncoding(t *testing.T) {
 	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
 	defer os.Remove(tmpFile)
@@ -78,25 +157,68 @@ func TestRecordLogJSONEncoding(t *testing.T) {
 		Attributes: []log.Attrb{log.Attr("key", "value")},
 	}
 
-	writer.RecordLog(entry)
-	writer.Close()
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
 
 	content, err := os.ReadFile(tmpFile)
 	assert.NoError(t, err)
 
 	assert.Contains(t, string(content), `"app_name": "TestApp"`)
 	assert.Contains(t, string(content), `"message": "Test log message"`)
+	assert.Contains(t, string(content), `"attributes": {`)
 }
 
-func TestRotateFile(t *testing.T) {
+func TestRecordLogJSONRoundTrip(t *testing.T) {
 	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
 	defer os.Remove(tmpFile)
 
+	writer, err := NewWriter(tmpFile, DuplicateKeys(log.SuffixDuplicates))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.SetEncoding(JSONEncoding))
+
+	entries := []log.Entry{
+		{
+			Timestamp:  time.Now().UTC().Truncate(time.Second),
+			Level:      log.InfoLevel,
+			AppName:    "TestApp",
+			Message:    "first",
+			Attributes: []log.Attrb{log.String("key", "a"), log.Int("key", 2)},
+		},
+		{
+			Timestamp: time.Now().UTC().Truncate(time.Second),
+			Level:     log.ErrorLevel,
+			AppName:   "TestApp",
+			Message:   "second",
+		},
+	}
+	for _, entry := range entries {
+		assert.NoError(t, writer.RecordLog(entry))
+	}
+	assert.NoError(t, writer.Close(context.Background()))
+
+	file, err := os.Open(tmpFile)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	dec := log.NewDecoder(file)
+	first, err := dec.Decode()
+	assert.NoError(t, err)
+	entries[0].Attributes = []log.Attrb{log.String("key", "a"), log.Int("key#2", 2)}
+	assert.Equal(t, entries[0], first)
+
+	second, err := dec.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, entries[1], second)
+}
+
+func TestRotateFile(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
 	writer, err := NewWriter(tmpFile)
 	assert.NoError(t, err)
 
 	// Simulate exceeding max file size
-	writer.currentSize = maxFileSize + 1
+	writer.currentSize = DefaultMaxSize + 1
 
 	entry := log.Entry{
 		Timestamp:  time.Now(),
@@ -106,12 +228,176 @@ func TestRotateFile(t *testing.T) {
 		Attributes: []log.Attrb{log.Attr("key", "value")},
 	}
 
-	writer.RecordLog(entry)
-	writer.Close()
+	assert.NoError(t, writer.RecordLog(entry))
+	writer.Close(context.Background())
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+	assert.FileExists(t, backups[0].path)
+	assert.FileExists(t, tmpFile)
+}
 
-	rotatedFile := fmt.Sprintf("%s.%d", tmpFile, time.Now().Unix())
+func TestRotateFileNamesDoNotCollide(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
 
-	assert.FileExists(t, rotatedFile)
+	writer, err := NewWriter(tmpFile, MaxSize(1))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: fmt.Sprint("entry ", i)}))
+	}
+	writer.Close(context.Background())
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 3, "Rotations at the same time should not overwrite each other") {
+		assert.Equal(t, tmpFile+".20240102T030405.000000000", backups[0].path)
+		assert.Equal(t, tmpFile+".20240102T030405.000000000-1", backups[1].path)
+		assert.Equal(t, tmpFile+".20240102T030405.000000000-2", backups[2].path)
+	}
+}
+
+func TestRotateEvery(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	now := time.Date(2024, 1, 2, 10, 59, 0, 0, time.UTC)
+
+	writer, err := NewWriter(tmpFile, RotateEvery(time.Hour))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+	writer.nextRotation = nextBoundary(now, time.Hour)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "before"}))
+	now = now.Add(time.Minute)
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "after"}))
+	writer.Close(context.Background())
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		content, err := os.ReadFile(backups[0].path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "message:before")
+		assert.NotContains(t, string(content), "message:after")
+	}
+	content, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "message:after")
+	assert.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), writer.nextRotation)
+}
+
+func TestRotateEveryRotatesStaleFile(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte("yesterday\n"), 0644))
+	yesterday := time.Now().AddDate(0, 0, -1)
+	assert.NoError(t, os.Chtimes(tmpFile, yesterday, yesterday))
+
+	writer, err := NewWriter(tmpFile, RotateEvery(24*time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "today"}))
+	writer.Close(context.Background())
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1, "A file from a previous day should be rotated")
+}
+
+func TestNextBoundary(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	tests := []struct {
+		t        time.Time
+		interval time.Duration
+		expected time.Time
+	}{
+		{time.Date(2024, 1, 2, 10, 30, 0, 0, loc), time.Hour, time.Date(2024, 1, 2, 11, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 2, 11, 0, 0, 0, loc), time.Hour, time.Date(2024, 1, 2, 12, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 2, 10, 30, 0, 0, loc), 24 * time.Hour, time.Date(2024, 1, 3, 0, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 2, 10, 30, 0, 0, loc), 6 * time.Hour, time.Date(2024, 1, 2, 12, 0, 0, 0, loc)},
+		{time.Date(2024, 1, 2, 23, 50, 0, 0, loc), 15 * time.Minute, time.Date(2024, 1, 3, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, nextBoundary(tt.t, tt.interval), "%v every %v", tt.t, tt.interval)
+	}
+}
+
+func TestMaxBackupsAndMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "testlog.txt")
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	// An unrelated file with the same prefix should never be deleted.
+	unrelated := tmpFile + ".bak"
+	assert.NoError(t, os.WriteFile(unrelated, nil, 0644))
+
+	writer, err := NewWriter(tmpFile, MaxSize(1), MaxBackups(2), MaxAge(72*time.Hour))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+
+	for _, day := range []int{1, 5, 8, 9, 10} {
+		now = time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+		assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "entry"}))
+	}
+	writer.Close(context.Background())
+
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		assert.Equal(t, time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), backups[0].rotated)
+		assert.Equal(t, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), backups[1].rotated)
+	}
+	assert.FileExists(t, unrelated)
+
+	// Only the age limit applies with MaxAge alone.
+	writer, err = NewWriter(tmpFile, MaxSize(1), MaxAge(12*time.Hour))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now.Add(time.Hour) }
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "entry"}))
+	writer.Close(context.Background())
+
+	backups, err = writer.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2, "The backups of the 10th and the new one should be kept")
+}
+
+func TestMaxBackupsLegacyBackups(t *testing.T) {
+	dir := t.TempDir()
+	tmpFile := filepath.Join(dir, "testlog.txt")
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	// The previous versions named the backups after the Unix time, logrotate numbers them.
+	legacy := fmt.Sprintf("%s.%d", tmpFile, now.Add(-time.Hour).Unix())
+	numbered := tmpFile + ".1"
+	for _, name := range []string{legacy, numbered} {
+		assert.NoError(t, os.WriteFile(name, nil, 0644))
+	}
+
+	writer, err := NewWriter(tmpFile, MaxSize(1), MaxBackups(1))
+	assert.NoError(t, err)
+	writer.now = func() time.Time { return now }
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "entry"}))
+	writer.Close(context.Background())
+
+	assert.NoFileExists(t, legacy, "The legacy backup should count towards MaxBackups")
+	assert.FileExists(t, numbered)
+	backups, err := writer.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 1) {
+		assert.Equal(t, now, backups[0].rotated)
+	}
+}
+
+func TestNewWriterInvalidRotationOptions(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "testlog.txt")
+
+	for _, opt := range []Option{MaxSize(-1), RotateEvery(-time.Hour), MaxBackups(-1), MaxAge(-time.Hour)} {
+		writer, err := NewWriter(tmpFile, opt)
+		assert.Error(t, err)
+		assert.Nil(t, writer)
+	}
+	assert.NoFileExists(t, tmpFile, "The file should not be created with invalid options")
 }
 
 func TestClose(t *testing.T) {
@@ -121,8 +407,44 @@ func TestClose(t *testing.T) {
 	writer, err := NewWriter(tmpFile)
 	assert.NoError(t, err)
 
-	writer.Close()
+	writer.Close(context.Background())
 
 	_, err = writer.file.Write([]byte("test"))
 	assert.Error(t, err)
 }
+
+func TestRecordLogAfterClose(t *testing.T) {
+	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
+	defer os.Remove(tmpFile)
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.NoError(t, writer.Close(context.Background()), "Closing twice should not fail")
+
+	err = writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.InfoLevel, Message: "Test log message"})
+	assert.Error(t, err)
+}
+
+func TestRecordLogFlushesSevereLevels(t *testing.T) {
+	tmpFile := filepath.Join(os.TempDir(), "testlog.txt")
+	defer os.Remove(tmpFile)
+
+	writer, err := NewWriter(tmpFile)
+	assert.NoError(t, err)
+	defer writer.Close(context.Background())
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.NoticeLevel, Message: "Buffered message"}))
+
+	content, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "Buffered message")
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Timestamp: time.Now(), Level: log.CriticalLevel, Message: "Critical message"}))
+
+	content, err = os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "Buffered message")
+	assert.Contains(t, string(content), "level:CRITICAL")
+}
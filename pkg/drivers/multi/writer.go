@@ -0,0 +1,115 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ralugr/datacollector/pkg/app"
+	"github.com/ralugr/datacollector/pkg/log"
+)
+
+// Destination is one of the drivers a Writer sends the entries to.
+type Destination struct {
+	// Driver receives the entries, e.g. cli.Writer or file.Writer.
+	Driver app.Driver
+	// MinLevel is the lowest level forwarded to the driver. All levels are forwarded when empty.
+	MinLevel log.Level
+	// Encoding is set on the driver when the Writer is created.
+	// When empty, the driver follows the encoding passed to Writer.SetEncoding.
+	Encoding string
+}
+
+// Writer is a driver that forwards every entry to several destinations.
+// Each destination is written independently: an error or a panic in one of them
+// is reported by RecordLog, but does not prevent the others from receiving the entry.
+type Writer struct {
+	destinations []Destination
+}
+
+// NewWriter creates a Writer for the given destinations and applies their encodings.
+func NewWriter(destinations ...Destination) (*Writer, error) {
+	if len(destinations) == 0 {
+		return nil, fmt.Errorf("at least one destination is required")
+	}
+
+	for i, d := range destinations {
+		if d.Driver == nil {
+			return nil, fmt.Errorf("destination %d has no driver", i)
+		}
+		if d.MinLevel != "" && !log.IsValid(d.MinLevel, d.MinLevel) {
+			return nil, fmt.Errorf("destination %d has an invalid level: %v", i, d.MinLevel)
+		}
+		if d.Encoding != "" {
+			if err := d.Driver.SetEncoding(d.Encoding); err != nil {
+				return nil, fmt.Errorf("destination %d: %w", i, err)
+			}
+		}
+	}
+
+	return &Writer{
+		destinations: destinations,
+	}, nil
+}
+
+// SetEncoding sets the encoding of the destinations that were created without one.
+func (w *Writer) SetEncoding(encoding string) error {
+	return w.each(func(d Destination) error {
+		if d.Encoding != "" {
+			return nil
+		}
+		return d.Driver.SetEncoding(encoding)
+	})
+}
+
+// RecordLog sends the entry to every destination whose MinLevel allows it.
+func (w *Writer) RecordLog(logInfo log.Entry) error {
+	return w.each(func(d Destination) error {
+		if d.MinLevel != "" && !log.IsValid(d.MinLevel, logInfo.Level) {
+			return nil
+		}
+		return d.Driver.RecordLog(logInfo)
+	})
+}
+
+// Flush flushes every destination.
+func (w *Writer) Flush(ctx context.Context) error {
+	return w.each(func(d Destination) error {
+		return d.Driver.Flush(ctx)
+	})
+}
+
+// Sync syncs every destination, the ones that do not implement app.Syncer are flushed.
+func (w *Writer) Sync(ctx context.Context) error {
+	return w.each(func(d Destination) error {
+		return app.SyncDriver(ctx, d.Driver)
+	})
+}
+
+// Close closes every destination.
+func (w *Writer) Close(ctx context.Context) error {
+	return w.each(func(d Destination) error {
+		return d.Driver.Close(ctx)
+	})
+}
+
+// each calls fn for every destination and joins the errors.
+func (w *Writer) each(fn func(d Destination) error) error {
+	var errs []error
+	for i, d := range w.destinations {
+		if err := call(fn, d); err != nil {
+			errs = append(errs, fmt.Errorf("destination %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// call runs fn and turns a panic into an error, so one faulty driver cannot stop the others.
+func call(fn func(d Destination) error, d Destination) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("driver panicked: %v", r)
+		}
+	}()
+	return fn(d)
+}
@@ -0,0 +1,145 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ralugr/datacollector/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDriver struct {
+	entries  []log.Entry
+	encoding string
+	closed   bool
+	err      error
+	panics   bool
+}
+
+func (d *fakeDriver) RecordLog(logInfo log.Entry) error {
+	if d.panics {
+		panic("broken driver")
+	}
+	if d.err != nil {
+		return d.err
+	}
+	d.entries = append(d.entries, logInfo)
+	return nil
+}
+
+func (d *fakeDriver) SetEncoding(encoding string) error {
+	d.encoding = encoding
+	return nil
+}
+
+func (d *fakeDriver) Flush(ctx context.Context) error {
+	return d.err
+}
+
+func (d *fakeDriver) Close(ctx context.Context) error {
+	d.closed = true
+	return d.err
+}
+
+type syncingDriver struct {
+	fakeDriver
+	synced bool
+}
+
+func (d *syncingDriver) Sync(ctx context.Context) error {
+	d.synced = true
+	return d.err
+}
+
+func TestNewWriter(t *testing.T) {
+	console := &fakeDriver{}
+	file := &fakeDriver{}
+
+	writer, err := NewWriter(
+		Destination{Driver: console, MinLevel: log.ErrorLevel, Encoding: "plain"},
+		Destination{Driver: file},
+	)
+	assert.NoError(t, err)
+	assert.NotNil(t, writer)
+	assert.Equal(t, "plain", console.encoding)
+	assert.Empty(t, file.encoding)
+
+	assert.NoError(t, writer.SetEncoding("json"))
+	assert.Equal(t, "plain", console.encoding, "Explicit encoding should not change")
+	assert.Equal(t, "json", file.encoding)
+}
+
+func TestNewWriterInvalidInput(t *testing.T) {
+	_, err := NewWriter()
+	assert.Error(t, err)
+
+	_, err = NewWriter(Destination{})
+	assert.Error(t, err)
+
+	_, err = NewWriter(Destination{Driver: &fakeDriver{}, MinLevel: "UNKNOWN"})
+	assert.Error(t, err)
+}
+
+func TestRecordLogMinLevel(t *testing.T) {
+	console := &fakeDriver{}
+	file := &fakeDriver{}
+
+	writer, err := NewWriter(
+		Destination{Driver: console, MinLevel: log.ErrorLevel},
+		Destination{Driver: file},
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "info"}))
+	assert.NoError(t, writer.RecordLog(log.Entry{Level: log.ErrorLevel, Message: "error"}))
+
+	assert.Len(t, console.entries, 1, "Console should only receive errors")
+	assert.Len(t, file.entries, 2, "File should receive every entry")
+}
+
+func TestRecordLogFailingDestination(t *testing.T) {
+	failing := &fakeDriver{err: errors.New("disk full")}
+	panicking := &fakeDriver{panics: true}
+	healthy := &fakeDriver{}
+
+	writer, err := NewWriter(
+		Destination{Driver: failing},
+		Destination{Driver: panicking},
+		Destination{Driver: healthy},
+	)
+	assert.NoError(t, err)
+
+	err = writer.RecordLog(log.Entry{Level: log.InfoLevel, Message: "info"})
+
+	assert.ErrorIs(t, err, failing.err)
+	assert.ErrorContains(t, err, "panicked")
+	assert.Len(t, healthy.entries, 1, "Healthy destination should still receive the entry")
+}
+
+func TestClose(t *testing.T) {
+	failing := &fakeDriver{err: errors.New("close failed")}
+	healthy := &fakeDriver{}
+
+	writer, err := NewWriter(Destination{Driver: failing}, Destination{Driver: healthy})
+	assert.NoError(t, err)
+
+	err = writer.Close(context.Background())
+
+	assert.ErrorIs(t, err, failing.err)
+	assert.True(t, failing.closed)
+	assert.True(t, healthy.closed)
+}
+
+func TestSync(t *testing.T) {
+	syncing := &syncingDriver{}
+	failing := &fakeDriver{err: errors.New("flush failed")}
+
+	writer, err := NewWriter(Destination{Driver: syncing}, Destination{Driver: failing})
+	assert.NoError(t, err)
+
+	err = writer.Sync(context.Background())
+
+	assert.ErrorIs(t, err, failing.err, "Destinations without Sync should be flushed")
+	assert.True(t, syncing.synced)
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"slices"
+	"time"
+)
+
+// String creates a string attribute.
+func String(key string, value string) Attrb {
+	return Attrb{Key: key, Value: StringValue(value)}
+}
+
+// Int creates an int attribute, it is stored as an int64.
+func Int(key string, value int) Attrb {
+	return Attrb{Key: key, Value: IntValue(value)}
+}
+
+// Int64 creates an int64 attribute.
+func Int64(key string, value int64) Attrb {
+	return Attrb{Key: key, Value: Int64Value(value)}
+}
+
+// Uint64 creates a uint64 attribute.
+func Uint64(key string, value uint64) Attrb {
+	return Attrb{Key: key, Value: Uint64Value(value)}
+}
+
+// Float64 creates a float64 attribute.
+func Float64(key string, value float64) Attrb {
+	return Attrb{Key: key, Value: Float64Value(value)}
+}
+
+// Bool creates a bool attribute.
+func Bool(key string, value bool) Attrb {
+	return Attrb{Key: key, Value: BoolValue(value)}
+}
+
+// Duration creates a time.Duration attribute.
+func Duration(key string, value time.Duration) Attrb {
+	return Attrb{Key: key, Value: DurationValue(value)}
+}
+
+// Time creates a time.Time attribute.
+func Time(key string, value time.Time) Attrb {
+	return Attrb{Key: key, Value: TimeValue(value)}
+}
+
+// Err creates an error attribute with the "error" key.
+// The drivers write the message, the concrete type and the wrapped errors, see ErrorInfo. Use ErrStack to capture a stack trace.
+func Err(err error) Attrb {
+	return Attrb{Key: "error", Value: ErrorValue(err)}
+}
+
+// Any creates an attribute for any Go value, it is the same as Attr.
+func Any(key string, value any) Attrb {
+	return Attrb{Key: key, Value: AnyValue(value)}
+}
+
+// Group creates an attribute that nests attrs under key.
+// The JSON encoding writes a group as an object, the plain encoding writes its attributes with dotted keys, e.g. "http.method".
+// The attributes of a group without a key are inlined in the parent, and groups without attributes are omitted.
+func Group(key string, attrs ...Attrb) Attrb {
+	return Attrb{Key: key, Value: GroupValue(attrs...)}
+}
+
+// Flatten returns the attributes with the groups replaced by their attributes,
+// the keys are prefixed with the group keys, e.g. Group("http", Int("status", 200)) becomes "http.status".
+func Flatten(attrs []Attrb) []Attrb {
+	if !slices.ContainsFunc(attrs, isGroup) {
+		return attrs
+	}
+	return appendFlattened(make([]Attrb, 0, len(attrs)), "", attrs)
+}
+
+func appendFlattened(flattened []Attrb, prefix string, attrs []Attrb) []Attrb {
+	for _, attr := range attrs {
+		if !isGroup(attr) {
+			flattened = append(flattened, Attrb{Key: prefix + attr.Key, Value: attr.Value})
+			continue
+		}
+
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		flattened = appendFlattened(flattened, groupPrefix, attr.Value.Group())
+	}
+	return flattened
+}
+
+// inlineGroups returns the attributes with the groups without a key replaced by their attributes and the empty groups removed.
+func inlineGroups(attrs []Attrb) []Attrb {
+	if !slices.ContainsFunc(attrs, func(attr Attrb) bool {
+		return isGroup(attr) && (attr.Key == "" || len(attr.Value.Group()) == 0)
+	}) {
+		return attrs
+	}
+
+	inlined := make([]Attrb, 0, len(attrs))
+	for _, attr := range attrs {
+		switch {
+		case !isGroup(attr):
+			inlined = append(inlined, attr)
+		case attr.Key == "":
+			inlined = append(inlined, inlineGroups(attr.Value.Group())...)
+		case len(attr.Value.Group()) > 0:
+			inlined = append(inlined, attr)
+		}
+	}
+	return inlined
+}
+
+func isGroup(attr Attrb) bool {
+	return attr.Value.Kind() == KindGroup
+}
@@ -0,0 +1,58 @@
+package log
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	attrs := []Attrb{
+		String("component", "db"),
+		Group("http",
+			String("method", "GET"),
+			Group("response", Int("status", 200)),
+			Group("", Bool("cached", true)),
+			Group("empty"),
+		),
+	}
+
+	expected := []Attrb{
+		String("component", "db"),
+		String("http.method", "GET"),
+		Int("http.response.status", 200),
+		Bool("http.cached", true),
+	}
+	if got := Flatten(attrs); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestGroupMarshalJSON(t *testing.T) {
+	entry := Entry{
+		Attributes: []Attrb{
+			Group("http", String("method", "GET"), Group("response", Int("status", 200))),
+			Group("", String("inlined", "yes")),
+			Group("empty", Group("nested")),
+		},
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `"attributes":{"http":{"method":"GET","response":{"status":200}},"inlined":"yes","empty":{}}`
+	if !json.Valid(data) || !strings.Contains(string(data), expected) {
+		t.Errorf("expected %s in %s", expected, data)
+	}
+
+	var decoded Entry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.Attributes[0].Value.Equal(entry.Attributes[0].Value) {
+		t.Errorf("expected %v, got %v", entry.Attributes[0], decoded.Attributes[0])
+	}
+}
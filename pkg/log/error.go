@@ -0,0 +1,139 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames captured by ErrStack.
+const maxStackDepth = 32
+
+// ErrorInfo describes an error attribute: its message, its concrete type, the errors it wraps and
+// the stack trace captured by ErrStack.
+// The JSON encoding writes it as an object, e.g. {"message":"...","type":"*fs.PathError","causes":[...]}.
+type ErrorInfo struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	// Causes are the errors returned by Unwrap, one for a wrapped error and several for errors.Join.
+	Causes []ErrorInfo `json:"causes,omitempty"`
+	Stack  []Frame     `json:"stack,omitempty"`
+}
+
+// Frame is a function call in a stack trace.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line)
+}
+
+// stackError is the value of a KindError Value created by ErrStack.
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+// ErrStack creates an error attribute with the "error" key, like Err, and captures the stack trace of the caller.
+func ErrStack(err error) Attrb {
+	return Attrb{Key: "error", Value: errorValueWithStack(err, 3)}
+}
+
+// ErrorValueWithStack returns a Value for an error with the stack trace of the caller.
+// skip is the number of additional frames to skip, 0 captures the stack starting at the caller of ErrorValueWithStack.
+func ErrorValueWithStack(err error, skip int) Value {
+	return errorValueWithStack(err, skip+3)
+}
+
+func errorValueWithStack(err error, skip int) Value {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip, pcs)
+	return Value{kind: KindError, any: &stackError{err: err, pcs: pcs[:n]}}
+}
+
+// DescribeError returns the message, the type and the chain of wrapped errors of err.
+// The chain follows Unwrap() error and Unwrap() []error, as errors.Is does.
+func DescribeError(err error) ErrorInfo {
+	if err == nil {
+		return ErrorInfo{Message: "<nil>"}
+	}
+
+	info := ErrorInfo{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			info.Causes = []ErrorInfo{DescribeError(cause)}
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			if cause != nil {
+				info.Causes = append(info.Causes, DescribeError(cause))
+			}
+		}
+	}
+	return info
+}
+
+// String formats the error on one line for the plain encoding: the message, then the types of the
+// error chain in brackets and the stack trace, e.g. "open x: no such file [*fs.PathError > syscall.Errno]".
+func (e ErrorInfo) String() string {
+	var b strings.Builder
+	// Joined errors are separated by new lines, the plain encoding writes one entry per line.
+	b.WriteString(strings.ReplaceAll(e.Message, "\n", "; "))
+	if e.Type != "" {
+		b.WriteString(" [")
+		e.writeTypes(&b)
+		b.WriteString("]")
+	}
+
+	if len(e.Stack) > 0 {
+		b.WriteString(" stack:")
+		for _, frame := range e.Stack {
+			b.WriteString(" ")
+			b.WriteString(frame.String())
+		}
+	}
+	return b.String()
+}
+
+func (e ErrorInfo) writeTypes(b *strings.Builder) {
+	b.WriteString(e.Type)
+	switch len(e.Causes) {
+	case 0:
+	case 1:
+		b.WriteString(" > ")
+		e.Causes[0].writeTypes(b)
+	default:
+		b.WriteString(" > {")
+		for i, cause := range e.Causes {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			cause.writeTypes(b)
+		}
+		b.WriteString("}")
+	}
+}
+
+// ErrorInfo returns the description of a KindError value, including the stack trace captured by ErrStack.
+// It panics for other kinds.
+func (v Value) ErrorInfo() ErrorInfo {
+	info := DescribeError(v.Error())
+	if s, ok := v.any.(*stackError); ok {
+		frames := runtime.CallersFrames(s.pcs)
+		for {
+			frame, more := frames.Next()
+			info.Stack = append(info.Stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			if !more {
+				break
+			}
+		}
+	}
+	return info
+}
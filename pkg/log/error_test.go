@@ -0,0 +1,93 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribeError(t *testing.T) {
+	_, openErr := os.Open("/does/not/exist")
+	err := errors.Join(fmt.Errorf("loading config: %w", openErr), errors.New("second"))
+
+	info := DescribeError(err)
+
+	if info.Message != err.Error() || info.Type != "*errors.joinError" {
+		t.Errorf("unexpected description %+v", info)
+	}
+	if len(info.Causes) != 2 {
+		t.Fatalf("expected 2 causes, got %+v", info.Causes)
+	}
+
+	wrapped := info.Causes[0]
+	if wrapped.Type != "*fmt.wrapError" || len(wrapped.Causes) != 1 {
+		t.Fatalf("unexpected wrapped error %+v", wrapped)
+	}
+	var pathErr *fs.PathError
+	if !errors.As(openErr, &pathErr) || wrapped.Causes[0].Type != "*fs.PathError" {
+		t.Errorf("unexpected path error %+v", wrapped.Causes[0])
+	}
+	if wrapped.Causes[0].Causes[0].Type != "syscall.Errno" {
+		t.Errorf("expected the errno to be the last cause, got %+v", wrapped.Causes[0].Causes)
+	}
+}
+
+func TestErrorInfoString(t *testing.T) {
+	err := errors.Join(fmt.Errorf("wrapped: %w", errors.New("inner")), errors.New("second"))
+
+	expected := "wrapped: inner; second [*errors.joinError > {*fmt.wrapError > *errors.errorString, *errors.errorString}]"
+	if got := Err(err).Value.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestErrMarshalJSON(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", errors.New("inner"))
+
+	data, marshalErr := json.Marshal(Entry{Attributes: []Attrb{Err(err)}})
+	if marshalErr != nil {
+		t.Fatalf("unexpected error: %v", marshalErr)
+	}
+
+	expected := `"attributes":{"error":{"message":"wrapped: inner","type":"*fmt.wrapError",` +
+		`"causes":[{"message":"inner","type":"*errors.errorString"}]}}`
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("expected %s in %s", expected, data)
+	}
+}
+
+func TestErrStack(t *testing.T) {
+	err := errors.New("boom")
+	attr := ErrStack(err)
+
+	if attr.Key != "error" || attr.Value.Error() != err {
+		t.Errorf("unexpected attribute %v", attr)
+	}
+	if !attr.Value.Equal(ErrorValue(err)) {
+		t.Error("the stack trace should not change the error")
+	}
+
+	stack := attr.Value.ErrorInfo().Stack
+	if len(stack) == 0 || stack[0].Function != "github.com/ralugr/datacollector/pkg/log.TestErrStack" {
+		t.Fatalf("expected the stack to start at the caller, got %v", stack)
+	}
+	if !strings.HasSuffix(stack[0].File, "error_test.go") || stack[0].Line == 0 {
+		t.Errorf("unexpected frame %v", stack[0])
+	}
+	if !strings.Contains(attr.Value.String(), " stack: "+stack[0].String()) {
+		t.Errorf("expected the stack in %q", attr.Value.String())
+	}
+
+	var decoded struct {
+		Stack []Frame `json:"stack"`
+	}
+	data, _ := attr.Value.MarshalJSON()
+	if jsonErr := json.Unmarshal(data, &decoded); jsonErr != nil || !reflect.DeepEqual(stack, decoded.Stack) {
+		t.Errorf("expected the stack in %s", data)
+	}
+}
@@ -0,0 +1,247 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DuplicateKeys defines how attributes with the same key are encoded in a JSON object.
+type DuplicateKeys int
+
+const (
+	// KeepLast encodes the value of the last attribute with the key, at the position of the first one.
+	KeepLast DuplicateKeys = iota
+	// KeepFirst encodes the value of the first attribute with the key and ignores the others.
+	KeepFirst
+	// SuffixDuplicates keeps every attribute, the duplicates are renamed to key#2, key#3, ...
+	SuffixDuplicates
+)
+
+// jsonEntry is Entry without its methods, so it can be encoded and decoded with the default behaviour.
+type jsonEntry Entry
+
+// MarshalJSON encodes the entry with its attributes as a JSON object, see MarshalEntry.
+// Duplicate attribute keys keep the last value.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return MarshalEntry(e, KeepLast)
+}
+
+// MarshalEntry encodes the entry as JSON with its attributes as an object, e.g. "attributes":{"userID":"12345"}.
+// duplicates defines how attributes with the same key are encoded.
+func MarshalEntry(e Entry, duplicates DuplicateKeys) ([]byte, error) {
+	attributes, err := marshalAttributes(e.Attributes, duplicates)
+	if err != nil {
+		return nil, err
+	}
+	if string(attributes) == "{}" {
+		// Omitted like an empty attribute list.
+		attributes = nil
+	}
+
+	return json.Marshal(struct {
+		jsonEntry
+		Attributes json.RawMessage `json:"attributes,omitempty"`
+	}{
+		jsonEntry:  jsonEntry(e),
+		Attributes: attributes,
+	})
+}
+
+func marshalAttributes(attributes []Attrb, duplicates DuplicateKeys) (json.RawMessage, error) {
+	attributes = inlineGroups(attributes)
+	if len(attributes) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+
+	keys := make([]string, 0, len(attributes))
+	values := make(map[string]Value, len(attributes))
+	for _, attr := range attributes {
+		key := attr.Key
+		if _, ok := values[key]; ok {
+			switch duplicates {
+			case KeepFirst:
+				continue
+			case SuffixDuplicates:
+				key = suffixKey(values, key)
+			default:
+				values[key] = attr.Value
+				continue
+			}
+		}
+		keys = append(keys, key)
+		values[key] = attr.Value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalValue(values[key], duplicates)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode attribute %v: %w", key, err)
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalValue encodes value, the groups are encoded as objects with the same duplicates policy.
+func marshalValue(value Value, duplicates DuplicateKeys) ([]byte, error) {
+	if value.Kind() == KindGroup {
+		return marshalAttributes(value.Group(), duplicates)
+	}
+	return json.Marshal(value)
+}
+
+// suffixKey returns the first key#n, starting with n = 2, that is not used yet.
+func suffixKey(used map[string]Value, key string) string {
+	for n := 2; ; n++ {
+		suffixed := key + "#" + strconv.Itoa(n)
+		if _, ok := used[suffixed]; !ok {
+			return suffixed
+		}
+	}
+}
+
+// UnmarshalJSON decodes an entry encoded by MarshalJSON or MarshalEntry.
+// The attributes keep their order. JSON has fewer types than Value, so the values are decoded as the matching JSON type:
+// strings (including times) as KindString, integers (including durations) as KindInt64, other numbers as KindFloat64,
+// booleans as KindBool, objects as KindGroup, and arrays and null as KindAny.
+// The array of {"Key","Value"} objects written by older versions is also accepted.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		jsonEntry
+		Attributes json.RawMessage `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	attributes, err := unmarshalAttributes(decoded.Attributes)
+	if err != nil {
+		return err
+	}
+
+	*e = Entry(decoded.jsonEntry)
+	e.Attributes = attributes
+	return nil
+}
+
+func unmarshalAttributes(data json.RawMessage) ([]Attrb, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		var legacy []struct {
+			Key   string
+			Value json.RawMessage
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return nil, fmt.Errorf("unable to decode attributes: %w", err)
+		}
+		attributes := make([]Attrb, 0, len(legacy))
+		for _, attr := range legacy {
+			value, err := unmarshalValue(attr.Value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode attribute %v: %w", attr.Key, err)
+			}
+			attributes = append(attributes, Attrb{Key: attr.Key, Value: value})
+		}
+		return attributes, nil
+	}
+
+	// A map would lose the order of the attributes, so the object is read token by token.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("unable to decode attributes: expected an object or an array")
+	}
+
+	var attributes []Attrb
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode attributes: %w", err)
+		}
+		key := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("unable to decode attribute %v: %w", key, err)
+		}
+		value, err := unmarshalValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode attribute %v: %w", key, err)
+		}
+		attributes = append(attributes, Attrb{Key: key, Value: value})
+	}
+	return attributes, nil
+}
+
+func unmarshalValue(data json.RawMessage) (Value, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return AnyValue(nil), nil
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		err := json.Unmarshal(data, &s)
+		return StringValue(s), err
+	case 't', 'f':
+		var b bool
+		err := json.Unmarshal(data, &b)
+		return BoolValue(b), err
+	case 'n':
+		return AnyValue(nil), nil
+	case '{':
+		attrs, err := unmarshalAttributes(data)
+		return GroupValue(attrs...), err
+	case '[':
+		var v any
+		err := json.Unmarshal(data, &v)
+		return AnyValue(v), err
+	default:
+		if i, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+			return Int64Value(i), nil
+		}
+		f, err := strconv.ParseFloat(string(data), 64)
+		return Float64Value(f), err
+	}
+}
+
+// Decoder reads log entries from a stream of JSON values, e.g. a file written with the json encoding.
+type Decoder struct {
+	dec *json.Decoder
+}
+
+// NewDecoder returns a Decoder that reads entries from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// Decode reads the next entry, it returns io.EOF when there are no more entries.
+func (d *Decoder) Decode() (Entry, error) {
+	var entry Entry
+	err := d.dec.Decode(&entry)
+	return entry, err
+}
+
+// More reports whether there is another entry to read.
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
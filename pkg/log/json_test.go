@@ -0,0 +1,142 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryMarshalJSONAttributesObject(t *testing.T) {
+	entry := Entry{
+		Timestamp:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:      InfoLevel,
+		AppName:    "app",
+		Message:    "hello",
+		Attributes: []Attrb{String("userID", "12345"), Int("attempt", 3)},
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"timestamp":"2024-01-02T03:04:05Z","level":"INFO","app_name":"app","message":"hello",` +
+		`"attributes":{"userID":"12345","attempt":3}}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestMarshalEntryDuplicateKeys(t *testing.T) {
+	entry := Entry{
+		Attributes: []Attrb{String("k", "a"), String("other", "x"), String("k", "b"), String("k#2", "c"), String("k", "d")},
+	}
+
+	tests := []struct {
+		policy   DuplicateKeys
+		expected string
+	}{
+		{KeepLast, `{"k":"d","other":"x","k#2":"c"}`},
+		{KeepFirst, `{"k":"a","other":"x","k#2":"c"}`},
+		{SuffixDuplicates, `{"k":"a","other":"x","k#2":"b","k#2#2":"c","k#3":"d"}`},
+	}
+
+	for _, tt := range tests {
+		data, err := MarshalEntry(entry, tt.policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(data), `"attributes":`+tt.expected) {
+			t.Errorf("policy %v: expected attributes %s, got %s", tt.policy, tt.expected, data)
+		}
+	}
+}
+
+func TestEntryJSONRoundTrip(t *testing.T) {
+	entry := Entry{
+		Timestamp:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:         WarnLevel,
+		AppName:       "app",
+		Logger:        "billing",
+		Message:       "hello",
+		TransactionID: "txn",
+		SpanID:        "span",
+		ParentSpanID:  "parent",
+		Attributes: []Attrb{
+			String("s", "value"),
+			Int64("i", -3),
+			Float64("f", 1.5),
+			Bool("b", true),
+			Any("list", []any{"a", "b"}),
+			Any("nil", nil),
+		},
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded Entry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(entry, decoded) {
+		t.Errorf("expected %+v, got %+v", entry, decoded)
+	}
+}
+
+func TestEntryUnmarshalJSONLegacyAttributes(t *testing.T) {
+	data := `{"level":"INFO","message":"old","attributes":[{"Key":"k","Value":"v"},{"Key":"n","Value":2}]}`
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Attrb{String("k", "v"), Int64("n", 2)}
+	if !reflect.DeepEqual(expected, entry.Attributes) {
+		t.Errorf("expected %v, got %v", expected, entry.Attributes)
+	}
+}
+
+func TestEntryUnmarshalJSONInvalidAttributes(t *testing.T) {
+	var entry Entry
+	if err := json.Unmarshal([]byte(`{"attributes":"text"}`), &entry); err == nil {
+		t.Error("expected an error for attributes that are not an object")
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	input := `{
+  "level": "INFO",
+  "message": "first",
+  "attributes": {
+    "k": "v"
+  }
+}
+{"level":"ERROR","message":"second"}
+`
+	dec := NewDecoder(strings.NewReader(input))
+
+	first, err := dec.Decode()
+	if err != nil || first.Message != "first" || !reflect.DeepEqual([]Attrb{String("k", "v")}, first.Attributes) {
+		t.Errorf("unexpected first entry %+v, err: %v", first, err)
+	}
+
+	if !dec.More() {
+		t.Fatal("expected another entry")
+	}
+	second, err := dec.Decode()
+	if err != nil || second.Level != ErrorLevel || second.Attributes != nil {
+		t.Errorf("unexpected second entry %+v, err: %v", second, err)
+	}
+
+	if _, err := dec.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
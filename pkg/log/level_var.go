@@ -0,0 +1,39 @@
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// LevelVar is a log level that can be read and changed concurrently.
+// It is shared by an application and all its transactions, so the level can be changed while the application runs.
+// The zero value is DebugLevel.
+type LevelVar struct {
+	level atomic.Value
+}
+
+// NewLevelVar creates a LevelVar set to level.
+func NewLevelVar(level Level) (*LevelVar, error) {
+	v := &LevelVar{}
+	if err := v.Set(level); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Level returns the current level.
+func (v *LevelVar) Level() Level {
+	if level, ok := v.level.Load().(Level); ok {
+		return level
+	}
+	return DebugLevel
+}
+
+// Set changes the current level. Unknown levels are rejected.
+func (v *LevelVar) Set(level Level) error {
+	if !IsValid(level, level) {
+		return fmt.Errorf("Invalid value: %v", level)
+	}
+	v.level.Store(level)
+	return nil
+}
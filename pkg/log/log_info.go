@@ -1,25 +1,35 @@
 package log
 
 import (
+	"cmp"
+	"fmt"
 	"slices"
+	"sync"
 	"time"
 )
 
 // Entry represents a log entry containing metadata about a specific log event.
-// Includes json formatting
+// Includes json formatting, the attributes are encoded as a JSON object.
 type Entry struct {
 	Timestamp     time.Time `json:"timestamp"`
 	Level         Level     `json:"level"`
 	AppName       string    `json:"app_name"`
+	Logger        string    `json:"logger,omitempty"`
 	Message       string    `json:"message"`
-	Attributes    []Attrb   `json:"attributes,omitempty"`
+	Attributes    []Attrb   `json:"attributes,omitempty"` // Encoded as an object, see MarshalEntry
 	TransactionID string    `json:"transaction_id,omitempty"`
+	SpanID        string    `json:"span_id,omitempty"`
+	ParentSpanID  string    `json:"parent_span_id,omitempty"`
+	// Source is the location of the code that logged the entry, it is only set with config.AddSource.
+	Source *Frame `json:"source,omitempty"`
 }
 
 // Attrb represents a single key-value pair for log attributes.
+// Use the typed constructors (String, Int64, Bool, ...) or Attr to create attributes.
+// Value used to be an any, Attr(key, value) replaces the Attrb{Key: key, Value: value} literals written for it.
 type Attrb struct {
 	Key   string
-	Value any
+	Value Value
 }
 
 // Level defines a custom type.
@@ -29,27 +39,119 @@ type Level string
 // Predefined log levels for standard log severity.
 // Should be passed when creating a new data collector application.
 const (
-	DebugLevel Level = "DEBUG"
-	InfoLevel  Level = "INFO"
-	WarnLevel  Level = "WARNING"
-	ErrorLevel Level = "ERROR"
+	TraceLevel    Level = "TRACE"
+	DebugLevel    Level = "DEBUG"
+	InfoLevel     Level = "INFO"
+	NoticeLevel   Level = "NOTICE"
+	WarnLevel     Level = "WARNING"
+	ErrorLevel    Level = "ERROR"
+	CriticalLevel Level = "CRITICAL"
+	FatalLevel    Level = "FATAL"
 )
 
-// A list of log levels in order of increasing severity.
-// This is used internally to compare log levels.
-var logLevels = []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+// The numeric severity of every known level, a higher number is more severe.
+// The predefined severities match the log/slog levels (DEBUG is -4, INFO is 0, WARNING is 4, ERROR is 8).
+// This is used internally to compare log levels, custom levels are added by RegisterLevel.
+var (
+	severities = map[Level]int{
+		TraceLevel:    -8,
+		DebugLevel:    -4,
+		InfoLevel:     0,
+		NoticeLevel:   2,
+		WarnLevel:     4,
+		ErrorLevel:    8,
+		CriticalLevel: 12,
+		FatalLevel:    16,
+	}
+	severitiesMu sync.RWMutex
+)
 
 // Attr creates a new key-value pair attribute for a log entry.
 // This function should be used to add attributes to logs and transactions.
+// The value kind is picked from its Go type, see AnyValue. The typed constructors (String, Int64, ...) avoid boxing the value.
 func Attr(key string, value any) Attrb {
-	return Attrb{Key: key, Value: value}
+	return Attrb{Key: key, Value: AnyValue(value)}
 }
 
 // IsValid determines if a log entry's level is valid according to the application's logging configuration.
-// It compares the current log level with the application's configured log level.
+// It compares the severity of the current log level with the severity of the application's configured log level.
 func IsValid(appLogLevel Level, currentLogLevel Level) bool {
-	appLevelIndex := slices.Index(logLevels, appLogLevel)
-	currentLevelIndex := slices.Index(logLevels, currentLogLevel)
+	severitiesMu.RLock()
+	defer severitiesMu.RUnlock()
+
+	appSeverity, appOk := severities[appLogLevel]
+	currentSeverity, currentOk := severities[currentLogLevel]
+
+	return appOk && currentOk && currentSeverity >= appSeverity
+}
+
+// IsKnown reports whether level is a predefined or a registered level.
+func IsKnown(level Level) bool {
+	_, ok := Severity(level)
+	return ok
+}
+
+// Severity returns the numeric severity of a predefined or a registered level.
+func Severity(level Level) (int, bool) {
+	severitiesMu.RLock()
+	defer severitiesMu.RUnlock()
+
+	severity, ok := severities[level]
+	return severity, ok
+}
+
+// RegisterLevel adds a custom level with the given numeric severity, e.g. a level between
+// ERROR (8) and CRITICAL (12). Custom levels can be used like the predefined ones.
+// It returns an error if the level is already known or if another level has the same severity.
+func RegisterLevel(level Level, severity int) error {
+	if level == "" {
+		return fmt.Errorf("level name is required")
+	}
+
+	severitiesMu.Lock()
+	defer severitiesMu.Unlock()
+
+	if _, ok := severities[level]; ok {
+		return fmt.Errorf("level %v is already registered", level)
+	}
+	for other, otherSeverity := range severities {
+		if otherSeverity == severity {
+			return fmt.Errorf("severity %v is already used by level %v", severity, other)
+		}
+	}
+
+	severities[level] = severity
+	return nil
+}
+
+// Levels returns the known levels in order of increasing severity.
+func Levels() []Level {
+	severitiesMu.RLock()
+	defer severitiesMu.RUnlock()
+
+	levels := make([]Level, 0, len(severities))
+	for level := range severities {
+		levels = append(levels, level)
+	}
+	slices.SortFunc(levels, func(a, b Level) int {
+		return cmp.Compare(severities[a], severities[b])
+	})
+	return levels
+}
+
+// LevelFor returns the most severe known level whose severity is at most severity,
+// or the least severe level if there is none. It maps numeric levels, such as the slog levels, to a Level.
+func LevelFor(severity int) Level {
+	levels := Levels()
+
+	severitiesMu.RLock()
+	defer severitiesMu.RUnlock()
 
-	return appLevelIndex >= 0 && currentLevelIndex >= appLevelIndex
+	level := levels[0]
+	for _, l := range levels {
+		if severities[l] <= severity {
+			level = l
+		}
+	}
+	return level
 }
@@ -10,9 +10,9 @@ func TestAttr(t *testing.T) {
 		value    any
 		expected Attrb
 	}{
-		{"key1", "value1", Attrb{Key: "key1", Value: "value1"}},
-		{"key2", 123, Attrb{Key: "key2", Value: 123}},
-		{"empty", nil, Attrb{Key: "empty", Value: nil}},
+		{"key1", "value1", Attrb{Key: "key1", Value: StringValue("value1")}},
+		{"key2", 123, Attrb{Key: "key2", Value: Int64Value(123)}},
+		{"empty", nil, Attrb{Key: "empty", Value: AnyValue(nil)}},
 	}
 
 	for _, tt := range tests {
@@ -54,7 +54,7 @@ func TestEntryInitialization(t *testing.T) {
 		Level:         InfoLevel,
 		AppName:       "TestApp",
 		Message:       "Test Message",
-		Attributes:    []Attrb{{Key: "key", Value: "value"}},
+		Attributes:    []Attrb{{Key: "key", Value: StringValue("value")}},
 		TransactionID: "12345",
 	}
 
@@ -78,7 +78,109 @@ func TestEntryInitialization(t *testing.T) {
 		t.Errorf("Entry TransactionID mismatch. Expected %v, got %v", "12345", entry.TransactionID)
 	}
 
-	if len(entry.Attributes) != 1 || entry.Attributes[0].Key != "key" || entry.Attributes[0].Value != "value" {
+	if len(entry.Attributes) != 1 || entry.Attributes[0].Key != "key" || entry.Attributes[0].Value != StringValue("value") {
 		t.Errorf("Entry Attributes mismatch. Expected key-value pair {key, value}, got %v", entry.Attributes)
 	}
 }
+
+func TestLevelVar(t *testing.T) {
+	var zero LevelVar
+	if zero.Level() != DebugLevel {
+		t.Errorf("LevelVar zero value mismatch. Expected %v, got %v", DebugLevel, zero.Level())
+	}
+
+	v, err := NewLevelVar(WarnLevel)
+	if err != nil {
+		t.Fatalf("NewLevelVar() failed: %v", err)
+	}
+	if v.Level() != WarnLevel {
+		t.Errorf("LevelVar level mismatch. Expected %v, got %v", WarnLevel, v.Level())
+	}
+
+	if err := v.Set("UNKNOWN"); err == nil {
+		t.Errorf("LevelVar.Set() should reject unknown levels")
+	}
+	if v.Level() != WarnLevel {
+		t.Errorf("LevelVar level should not change for invalid input, got %v", v.Level())
+	}
+
+	if _, err := NewLevelVar("UNKNOWN"); err == nil {
+		t.Errorf("NewLevelVar() should reject unknown levels")
+	}
+}
+
+func TestIsValidExtendedLevels(t *testing.T) {
+	tests := []struct {
+		appLogLevel     Level
+		currentLogLevel Level
+		expected        bool
+	}{
+		{TraceLevel, TraceLevel, true},
+		{DebugLevel, TraceLevel, false},
+		{InfoLevel, NoticeLevel, true},
+		{NoticeLevel, InfoLevel, false},
+		{WarnLevel, NoticeLevel, false},
+		{ErrorLevel, CriticalLevel, true},
+		{CriticalLevel, ErrorLevel, false},
+		{CriticalLevel, FatalLevel, true},
+		{FatalLevel, CriticalLevel, false},
+	}
+
+	for _, tt := range tests {
+		result := IsValid(tt.appLogLevel, tt.currentLogLevel)
+		if result != tt.expected {
+			t.Errorf("IsValid() failed. For appLogLevel: %v and currentLogLevel: %v, expected %v, got %v", tt.appLogLevel, tt.currentLogLevel, tt.expected, result)
+		}
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	alert := Level("ALERT")
+	if err := RegisterLevel(alert, 10); err != nil {
+		t.Fatalf("RegisterLevel() failed: %v", err)
+	}
+	defer func() {
+		severitiesMu.Lock()
+		delete(severities, alert)
+		severitiesMu.Unlock()
+	}()
+
+	if !IsKnown(alert) {
+		t.Errorf("Registered level should be known")
+	}
+	if !IsValid(ErrorLevel, alert) || IsValid(alert, ErrorLevel) || !IsValid(alert, CriticalLevel) {
+		t.Errorf("Registered level should be ordered between ERROR and CRITICAL")
+	}
+
+	if err := RegisterLevel(alert, 11); err == nil {
+		t.Errorf("RegisterLevel() should reject a registered level")
+	}
+	if err := RegisterLevel("OTHER", 8); err == nil {
+		t.Errorf("RegisterLevel() should reject a used severity")
+	}
+	if err := RegisterLevel("", 20); err == nil {
+		t.Errorf("RegisterLevel() should reject an empty level")
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	tests := []struct {
+		severity int
+		expected Level
+	}{
+		{-100, TraceLevel},
+		{-4, DebugLevel},
+		{-1, DebugLevel},
+		{0, InfoLevel},
+		{3, NoticeLevel},
+		{8, ErrorLevel},
+		{12, CriticalLevel},
+		{100, FatalLevel},
+	}
+
+	for _, tt := range tests {
+		if result := LevelFor(tt.severity); result != tt.expected {
+			t.Errorf("LevelFor(%v) failed. Expected %v, got %v", tt.severity, tt.expected, result)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package log
+
+// Processor transforms the entries between the App and its driver, see config.Processors.
+// Process returns the entries to record instead of entry: none to drop it, one to keep or change it,
+// or several to split it.
+//
+// The Attributes slice of the entry may be shared with other entries, a processor must copy it before changing it.
+type Processor interface {
+	Process(entry Entry) []Entry
+}
+
+// ProcessorFunc adapts a function to the Processor interface.
+type ProcessorFunc func(entry Entry) []Entry
+
+func (f ProcessorFunc) Process(entry Entry) []Entry {
+	return f(entry)
+}
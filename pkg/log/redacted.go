@@ -0,0 +1,26 @@
+package log
+
+import (
+	"encoding/json"
+)
+
+// RedactionMask replaces the values that must not be written by the drivers.
+const RedactionMask = "[REDACTED]"
+
+// Redacted is a sensitive string, e.g. log.Attr("password", log.Redacted(password)).
+// It is formatted and encoded as RedactionMask, so the drivers never write its value.
+// The App redaction stage replaces it according to config.RedactionMode and counts it.
+type Redacted string
+
+func (r Redacted) String() string {
+	return RedactionMask
+}
+
+// GoString hides the value from the %#v verb as well.
+func (r Redacted) GoString() string {
+	return RedactionMask
+}
+
+func (r Redacted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactionMask)
+}
@@ -0,0 +1,355 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Kind is the kind of a Value.
+type Kind int
+
+const (
+	KindAny Kind = iota
+	KindBool
+	KindDuration
+	KindFloat64
+	KindInt64
+	KindString
+	KindTime
+	KindUint64
+	KindError
+	KindGroup
+)
+
+var kindNames = []string{
+	KindAny:      "Any",
+	KindBool:     "Bool",
+	KindDuration: "Duration",
+	KindFloat64:  "Float64",
+	KindInt64:    "Int64",
+	KindString:   "String",
+	KindTime:     "Time",
+	KindUint64:   "Uint64",
+	KindError:    "Error",
+	KindGroup:    "Group",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "<unknown log.Kind>"
+}
+
+// Value is the value of an attribute.
+// The common types (string, numbers, bool, time.Duration and time.Time) are stored without
+// boxing them in an interface, so creating them does not allocate.
+// Drivers can switch on Kind and use the matching accessor instead of reflection.
+type Value struct {
+	kind Kind
+	// num holds the bits of the numeric kinds, the nanoseconds of a duration or the Unix nanoseconds of a time.
+	num uint64
+	str string
+	// any holds the value of KindAny and KindError, the []Attrb of KindGroup, or the *time.Location of KindTime.
+	any any
+}
+
+// StringValue returns a Value for a string.
+func StringValue(value string) Value {
+	return Value{kind: KindString, str: value}
+}
+
+// IntValue returns a Value for an int.
+func IntValue(value int) Value {
+	return Int64Value(int64(value))
+}
+
+// Int64Value returns a Value for an int64.
+func Int64Value(value int64) Value {
+	return Value{kind: KindInt64, num: uint64(value)}
+}
+
+// Uint64Value returns a Value for a uint64.
+func Uint64Value(value uint64) Value {
+	return Value{kind: KindUint64, num: value}
+}
+
+// Float64Value returns a Value for a float64.
+func Float64Value(value float64) Value {
+	return Value{kind: KindFloat64, num: math.Float64bits(value)}
+}
+
+// BoolValue returns a Value for a bool.
+func BoolValue(value bool) Value {
+	var num uint64
+	if value {
+		num = 1
+	}
+	return Value{kind: KindBool, num: num}
+}
+
+// DurationValue returns a Value for a time.Duration.
+func DurationValue(value time.Duration) Value {
+	return Value{kind: KindDuration, num: uint64(value.Nanoseconds())}
+}
+
+// TimeValue returns a Value for a time.Time. The monotonic clock reading is discarded.
+func TimeValue(value time.Time) Value {
+	nanos := value.UnixNano()
+	if !time.Unix(0, nanos).Equal(value) {
+		// The time is outside the range of Unix nanoseconds, e.g. the zero time.
+		return Value{kind: KindTime, any: value.Round(0)}
+	}
+	return Value{kind: KindTime, num: uint64(nanos), any: value.Location()}
+}
+
+// ErrorValue returns a Value for an error.
+func ErrorValue(value error) Value {
+	return Value{kind: KindError, any: value}
+}
+
+// GroupValue returns a Value for a group of attributes.
+func GroupValue(attrs ...Attrb) Value {
+	return Value{kind: KindGroup, any: attrs}
+}
+
+// AnyValue returns a Value for any Go value, using the matching kind for the common types.
+func AnyValue(value any) Value {
+	switch v := value.(type) {
+	case string:
+		return StringValue(v)
+	case int:
+		return Int64Value(int64(v))
+	case int8:
+		return Int64Value(int64(v))
+	case int16:
+		return Int64Value(int64(v))
+	case int32:
+		return Int64Value(int64(v))
+	case int64:
+		return Int64Value(v)
+	case uint:
+		return Uint64Value(uint64(v))
+	case uint8:
+		return Uint64Value(uint64(v))
+	case uint16:
+		return Uint64Value(uint64(v))
+	case uint32:
+		return Uint64Value(uint64(v))
+	case uint64:
+		return Uint64Value(v)
+	case float32:
+		return Float64Value(float64(v))
+	case float64:
+		return Float64Value(v)
+	case bool:
+		return BoolValue(v)
+	case time.Duration:
+		return DurationValue(v)
+	case time.Time:
+		return TimeValue(v)
+	case error:
+		return ErrorValue(v)
+	case Value:
+		return v
+	case []Attrb:
+		return GroupValue(v...)
+	default:
+		return Value{kind: KindAny, any: value}
+	}
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// Any returns the value as a Go value, e.g. an int64 for KindInt64 or a []Attrb for KindGroup.
+func (v Value) Any() any {
+	switch v.kind {
+	case KindBool:
+		return v.Bool()
+	case KindDuration:
+		return v.Duration()
+	case KindFloat64:
+		return v.Float64()
+	case KindInt64:
+		return v.Int64()
+	case KindString:
+		return v.str
+	case KindTime:
+		return v.Time()
+	case KindUint64:
+		return v.num
+	case KindError:
+		return v.Error()
+	default:
+		return v.any
+	}
+}
+
+// String returns the value as a string. Unlike the other accessors, it works for every kind.
+// Errors are formatted with ErrorInfo.String.
+func (v Value) String() string {
+	switch v.kind {
+	case KindString:
+		return v.str
+	case KindBool:
+		return strconv.FormatBool(v.Bool())
+	case KindInt64:
+		return strconv.FormatInt(v.Int64(), 10)
+	case KindUint64:
+		return strconv.FormatUint(v.num, 10)
+	case KindFloat64:
+		return strconv.FormatFloat(v.Float64(), 'g', -1, 64)
+	case KindDuration:
+		return v.Duration().String()
+	case KindTime:
+		return v.Time().String()
+	case KindError:
+		return v.ErrorInfo().String()
+	default:
+		return fmt.Sprint(v.any)
+	}
+}
+
+// Bool returns the value of a KindBool value, it panics for other kinds.
+func (v Value) Bool() bool {
+	v.mustBe(KindBool)
+	return v.num == 1
+}
+
+// Int64 returns the value of a KindInt64 value, it panics for other kinds.
+func (v Value) Int64() int64 {
+	v.mustBe(KindInt64)
+	return int64(v.num)
+}
+
+// Uint64 returns the value of a KindUint64 value, it panics for other kinds.
+func (v Value) Uint64() uint64 {
+	v.mustBe(KindUint64)
+	return v.num
+}
+
+// Float64 returns the value of a KindFloat64 value, it panics for other kinds.
+func (v Value) Float64() float64 {
+	v.mustBe(KindFloat64)
+	return math.Float64frombits(v.num)
+}
+
+// Duration returns the value of a KindDuration value, it panics for other kinds.
+func (v Value) Duration() time.Duration {
+	v.mustBe(KindDuration)
+	return time.Duration(int64(v.num))
+}
+
+// Time returns the value of a KindTime value, it panics for other kinds.
+func (v Value) Time() time.Time {
+	v.mustBe(KindTime)
+	if t, ok := v.any.(time.Time); ok {
+		return t
+	}
+	return time.Unix(0, int64(v.num)).In(v.any.(*time.Location))
+}
+
+// Error returns the value of a KindError value, it panics for other kinds.
+func (v Value) Error() error {
+	v.mustBe(KindError)
+	if s, ok := v.any.(*stackError); ok {
+		return s.err
+	}
+	err, _ := v.any.(error)
+	return err
+}
+
+// Group returns the attributes of a KindGroup value, it panics for other kinds.
+func (v Value) Group() []Attrb {
+	v.mustBe(KindGroup)
+	return v.any.([]Attrb)
+}
+
+// Equal reports whether two values hold the same kind and Go value.
+func (v Value) Equal(other Value) bool {
+	if v.kind != other.kind {
+		return false
+	}
+	switch v.kind {
+	case KindTime:
+		return v.Time().Equal(other.Time())
+	case KindGroup:
+		return slices.EqualFunc(v.Group(), other.Group(), func(a, b Attrb) bool {
+			return a.Key == b.Key && a.Value.Equal(b.Value)
+		})
+	case KindError:
+		return equalAny(v.Error(), other.Error())
+	case KindAny:
+		return equalAny(v.any, other.any)
+	default:
+		return v.num == other.num && v.str == other.str
+	}
+}
+
+// equalAny compares a and b with ==, or with reflect.DeepEqual when their type is not comparable,
+// e.g. a slice, a map or a struct holding one in an interface field.
+func equalAny(a, b any) (equal bool) {
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+	if !t.Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+
+	defer func() {
+		if recover() != nil {
+			equal = reflect.DeepEqual(a, b)
+		}
+	}()
+	return a == b
+}
+
+// MarshalJSON encodes the value as the matching JSON type.
+// Durations are encoded as nanoseconds, times with RFC 3339, errors as an ErrorInfo object and groups as objects.
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch v.kind {
+	case KindString:
+		return json.Marshal(v.str)
+	case KindBool:
+		return strconv.AppendBool(nil, v.Bool()), nil
+	case KindInt64:
+		return strconv.AppendInt(nil, v.Int64(), 10), nil
+	case KindUint64:
+		return strconv.AppendUint(nil, v.num, 10), nil
+	case KindDuration:
+		return strconv.AppendInt(nil, v.Duration().Nanoseconds(), 10), nil
+	case KindFloat64:
+		f := v.Float64()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			// JSON has no representation for these values.
+			return json.Marshal(v.String())
+		}
+		return json.Marshal(f)
+	case KindTime:
+		return json.Marshal(v.Time())
+	case KindError:
+		return json.Marshal(v.ErrorInfo())
+	case KindGroup:
+		return marshalAttributes(v.Group(), KeepLast)
+	default:
+		return json.Marshal(v.any)
+	}
+}
+
+func (v Value) mustBe(kind Kind) {
+	if v.kind != kind {
+		panic(fmt.Sprintf("log.Value kind is %v, not %v", v.kind, kind))
+	}
+}
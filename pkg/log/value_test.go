@@ -0,0 +1,158 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedAttributes(t *testing.T) {
+	now := time.Now()
+	err := errors.New("boom")
+
+	tests := []struct {
+		attr     Attrb
+		kind     Kind
+		expected any
+	}{
+		{String("s", "value"), KindString, "value"},
+		{Int("i", 3), KindInt64, int64(3)},
+		{Int64("i64", -7), KindInt64, int64(-7)},
+		{Uint64("u64", 7), KindUint64, uint64(7)},
+		{Float64("f", 1.5), KindFloat64, 1.5},
+		{Bool("b", true), KindBool, true},
+		{Duration("d", time.Second), KindDuration, time.Second},
+		{Err(err), KindError, err},
+		{Any("m", map[string]int{}), KindAny, nil},
+	}
+
+	for _, tt := range tests {
+		if tt.attr.Value.Kind() != tt.kind {
+			t.Errorf("%s: expected kind %v, got %v", tt.attr.Key, tt.kind, tt.attr.Value.Kind())
+		}
+		if tt.expected != nil && tt.attr.Value.Any() != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.attr.Key, tt.expected, tt.attr.Value.Any())
+		}
+	}
+
+	if got := Time("t", now).Value.Time(); !got.Equal(now) {
+		t.Errorf("expected time %v, got %v", now, got)
+	}
+	if got := Time("zero", time.Time{}).Value.Time(); !got.IsZero() {
+		t.Errorf("expected zero time, got %v", got)
+	}
+}
+
+func TestAnyValueUsesKinds(t *testing.T) {
+	if !AnyValue(42).Equal(Int64Value(42)) {
+		t.Error("int should be stored as KindInt64")
+	}
+	if !AnyValue(uint8(4)).Equal(Uint64Value(4)) {
+		t.Error("uint8 should be stored as KindUint64")
+	}
+	if !AnyValue(time.Minute).Equal(DurationValue(time.Minute)) {
+		t.Error("time.Duration should be stored as KindDuration")
+	}
+	if !AnyValue(StringValue("x")).Equal(StringValue("x")) {
+		t.Error("a Value should not be wrapped again")
+	}
+}
+
+type sliceError []string
+
+func (e sliceError) Error() string {
+	return strings.Join(e, ", ")
+}
+
+func TestValueEqualUncomparable(t *testing.T) {
+	tests := []struct {
+		a, b     Value
+		expected bool
+	}{
+		{AnyValue([]string{"a"}), AnyValue([]string{"a"}), true},
+		{AnyValue([]string{"a"}), AnyValue([]string{"b"}), false},
+		{AnyValue(map[string]int{"a": 1}), AnyValue(map[string]int{"a": 1}), true},
+		{AnyValue(struct{ V any }{[]int{1}}), AnyValue(struct{ V any }{[]int{1}}), true},
+		{AnyValue([]string{"a"}), AnyValue([2]string{"a"}), false},
+		{ErrorValue(sliceError{"a", "b"}), ErrorValue(sliceError{"a", "b"}), true},
+		{ErrorValue(sliceError{"a"}), ErrorValue(errors.New("a")), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Equal(tt.b); got != tt.expected {
+			t.Errorf("%v.Equal(%v) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestValueAccessorPanicsOnWrongKind(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	StringValue("x").Int64()
+}
+
+func TestValueString(t *testing.T) {
+	tests := []struct {
+		value    Value
+		expected string
+	}{
+		{StringValue("text"), "text"},
+		{Int64Value(-3), "-3"},
+		{Float64Value(0.25), "0.25"},
+		{BoolValue(false), "false"},
+		{DurationValue(1500 * time.Millisecond), "1.5s"},
+		{ErrorValue(errors.New("boom")), "boom [*errors.errorString]"},
+		{ErrorValue(nil), "<nil>"},
+		{AnyValue([]int{1, 2}), "[1 2]"},
+	}
+
+	for _, tt := range tests {
+		if tt.value.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, tt.value.String())
+		}
+	}
+}
+
+func TestValueMarshalJSON(t *testing.T) {
+	attributes := []Attrb{
+		String("s", "a\"b"),
+		Int64("i", 3),
+		Bool("b", true),
+		Duration("d", time.Millisecond),
+		Time("t", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Err(errors.New("boom")),
+		Any("list", []int{1, 2}),
+	}
+	expected := `[{"Key":"s","Value":"a\"b"},{"Key":"i","Value":3},{"Key":"b","Value":true},` +
+		`{"Key":"d","Value":1000000},{"Key":"t","Value":"2024-01-02T03:04:05Z"},` +
+		`{"Key":"error","Value":{"message":"boom","type":"*errors.errorString"}},{"Key":"list","Value":[1,2]}]`
+
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	attr := Attr("password", Redacted("hunter2"))
+
+	if attr.Value.String() != RedactionMask {
+		t.Errorf("expected %q, got %q", RedactionMask, attr.Value.String())
+	}
+	data, err := json.Marshal(attr.Value)
+	if err != nil || string(data) != `"[REDACTED]"` {
+		t.Errorf("expected the mask, got %s, err: %v", data, err)
+	}
+	if s := fmt.Sprintf("%v %+v %#v", attr, attr, Redacted("hunter2")); strings.Contains(s, "hunter2") {
+		t.Errorf("the value should not be formatted, got %q", s)
+	}
+}